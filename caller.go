@@ -0,0 +1,102 @@
+package lumber
+
+import (
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// directory containing the source files of this package
+var pkgDir string
+
+func init() {
+	_, file, _, _ := runtime.Caller(0)
+	pkgDir = filepath.Dir(file)
+}
+
+// Returns the stack frame of the code that called into the logger. Frames belonging to this
+// package are skipped, so the result is the same whether the call came through a package-level
+// function, a MultiLogger, or directly through a logger's methods.
+func callerFrame() *runtime.Frame {
+	pcs := make([]uintptr, 32)
+	// skip runtime.Callers and callerFrame
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	for {
+		frame, more := frames.Next()
+		if !isLumberFrame(frame) {
+			return &frame
+		}
+		if !more {
+			return nil
+		}
+	}
+}
+
+// Reports whether the frame belongs to this package. Test files are treated as callers.
+func isLumberFrame(frame runtime.Frame) bool {
+	return filepath.Dir(frame.File) == pkgDir && !strings.HasSuffix(frame.File, "_test.go")
+}
+
+// capture holds the settings for call site details recorded with each message
+type capture struct {
+	showCaller, stackTrace bool
+	stackLevel             Level
+}
+
+// Build a message, capturing the caller and stack trace if enabled. This must run on the
+// goroutine that made the logging call.
+func (c *capture) newMessage(lvl Level, m string) *Message {
+	msg := &Message{level: lvl, m: m, time: time.Now()}
+	if c.showCaller {
+		msg.caller = callerFrame()
+	}
+	if c.wantStack(lvl) {
+		msg.stack = callerStack()
+	}
+	return msg
+}
+
+// Returns a copy of the settings
+func (c *capture) captureSettings() capture {
+	return *c
+}
+
+// Reports whether messages of level lvl should carry a stack trace
+func (c *capture) wantStack(lvl Level) bool {
+	return c.stackTrace && lvl >= c.stackLevel
+}
+
+// Returns the stack trace of the current goroutine, starting at the code that called into the
+// logger. Frames are rendered in the same layout the runtime uses for panics: the function
+// name, then the file and line indented by a tab.
+func callerStack() string {
+	pcs := make([]uintptr, 64)
+	// skip runtime.Callers and callerStack
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+	buf := []byte{}
+	inLumber := true
+	for {
+		frame, more := frames.Next()
+		if inLumber && isLumberFrame(frame) {
+			if !more {
+				break
+			}
+			continue
+		}
+		inLumber = false
+		buf = append(buf, frame.Function...)
+		buf = append(buf, "()\n\t"...)
+		buf = append(buf, frame.File...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(frame.Line), 10)
+		buf = append(buf, '\n')
+		if !more {
+			break
+		}
+	}
+	return string(buf)
+}
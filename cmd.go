@@ -0,0 +1,81 @@
+package lumber
+
+import (
+	"os/exec"
+	"path/filepath"
+	"sync"
+)
+
+const (
+	// default maximum length of a line of command output
+	MAXLINELEN = 64 * 1024
+)
+
+// CmdCapture logs the output of a command line by line. Every message has "cmd" and "pid"
+// fields identifying the process.
+type CmdCapture struct {
+	cmd            *exec.Cmd
+	stdout, stderr *Writer
+}
+
+// Attach the stdout and stderr of cmd to l, logged at the levels INFO and WARN map to in the
+// registered set. This must be called before the command is started. Use the Wait or Run methods
+// of the returned CmdCapture instead of the ones of cmd, so partial lines are logged when the
+// process exits.
+func CaptureCmd(cmd *exec.Cmd, l Logger) *CmdCapture {
+	c := &CmdCapture{cmd: cmd}
+	// the writers share a lock so lines from stdout and stderr are logged one at a time
+	mu := &sync.Mutex{}
+	c.stdout = c.newWriter(mu, l, INFO)
+	c.stderr = c.newWriter(mu, l, WARN)
+	cmd.Stdout = c.stdout
+	cmd.Stderr = c.stderr
+	return c
+}
+
+func (c *CmdCapture) newWriter(mu *sync.Mutex, l Logger, lvl Level) *Writer {
+	return &Writer{
+		mu:        mu,
+		logger:    l,
+		level:     lvl,
+		maxLength: MAXLINELEN,
+		fields:    c.fields,
+	}
+}
+
+// Fields identifying the process, the pid is only known once the command has started
+func (c *CmdCapture) fields() []Field {
+	fields := []Field{{"cmd", filepath.Base(c.cmd.Path)}}
+	if c.cmd.Process != nil {
+		fields = append(fields, Field{"pid", c.cmd.Process.Pid})
+	}
+	return fields
+}
+
+// Sets the levels at which stdout and stderr are logged, see NewWriter
+func (c *CmdCapture) Levels(stdout, stderr Level) {
+	c.stdout.level = stdout
+	c.stderr.level = stderr
+}
+
+// Sets the length at which long lines are split
+func (c *CmdCapture) MaxLineLength(n int) {
+	c.stdout.maxLength = n
+	c.stderr.maxLength = n
+}
+
+// Start the command and wait for it to finish
+func (c *CmdCapture) Run() error {
+	if err := c.cmd.Start(); err != nil {
+		return err
+	}
+	return c.Wait()
+}
+
+// Wait for the command to finish, then log any output that didn't end with a newline
+func (c *CmdCapture) Wait() error {
+	err := c.cmd.Wait()
+	c.stdout.Flush()
+	c.stderr.Flush()
+	return err
+}
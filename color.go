@@ -0,0 +1,65 @@
+package lumber
+
+import (
+	"io"
+	"os"
+)
+
+const (
+	// color modes for ConsoleLogger
+	COLORAUTO = iota
+	COLORNEVER
+	COLORALWAYS
+)
+
+// Reports whether output to w should be colored in the given mode. In COLORAUTO mode, output
+// is colored if w is a terminal and neither NO_COLOR is set nor TERM is "dumb".
+func colorEnabled(mode int, w io.Writer) bool {
+	switch mode {
+	case COLORNEVER:
+		return false
+	case COLORALWAYS:
+		return true
+	}
+	if os.Getenv("NO_COLOR") != "" || os.Getenv("TERM") == "dumb" {
+		return false
+	}
+	if n, ok := w.(nopCloser); ok {
+		w = n.Writer
+	}
+	f, ok := w.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// Returns a copy of the set with the colors of some levels replaced. Colors are ANSI SGR
+// parameters such as "31" for red or "1;31" for bold red; "" disables coloring for a level.
+func (s *LevelSet) WithColors(colors map[Level]string) *LevelSet {
+	s2 := *s
+	s2.defs = append([]LevelDef(nil), s.defs...)
+	for i, def := range s2.defs {
+		if c, ok := colors[def.Level]; ok {
+			s2.defs[i].Color = c
+		}
+	}
+	return &s2
+}
+
+// Append the name of lvl, padded and in the level's color
+func (f *formatter) appendLevel(buf []byte, lvl Level) []byte {
+	name := f.levelName(lvl)
+	c := levelsOr(f.levels).Color(lvl)
+	if !f.color || c == "" {
+		return append(buf, name...)
+	}
+	n := len(levelsOr(f.levels).Name(lvl))
+	buf = append(buf, "\x1b["...)
+	buf = append(buf, c...)
+	buf = append(buf, 'm')
+	buf = append(buf, name[:n]...)
+	buf = append(buf, "\x1b[0m"...)
+	return append(buf, name[n:]...)
+}
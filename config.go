@@ -0,0 +1,384 @@
+package lumber
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LoggerConfig describes a logger in a configuration file read by LoadConfig, for example:
+//
+//	{
+//		"format": "json",
+//		"loggers": {
+//			"console": {"type": "console", "output": "stderr", "level": "WARN"},
+//			"file": {"type": "file", "output": "/var/log/app.log", "mode": "rotate",
+//				"maxLines": 100000, "maxRotate": 9, "level": "DEBUG"}
+//		}
+//	}
+//
+// Prefix, timeFormat and format are inherited by the members of a multi logger that don't set
+// them.
+type LoggerConfig struct {
+	// "console", "file" or "multi". The top level is always a multi logger.
+	Type string `json:"type"`
+	// the level of a console or file logger (default INFO), or the floor of a multi logger
+	Level *Level `json:"level"`
+	// "stdout" (the default) or "stderr" for a console logger, the path for a file logger
+	Output string `json:"output"`
+	// "append" (the default), "trunc", "backup" or "rotate"
+	Mode      string `json:"mode"`
+	MaxLines  int    `json:"maxLines"`
+	MaxRotate int    `json:"maxRotate"`
+	Prefix    string `json:"prefix"`
+	// defaults to TIMEFORMAT
+	TimeFormat *string `json:"timeFormat"`
+	// "text" (the default) or "json"
+	Format string `json:"format"`
+	// members of a multi logger, by name
+	Loggers map[string]*LoggerConfig `json:"loggers"`
+}
+
+// Config is a tree of loggers built from a configuration file
+type Config struct {
+	path   string
+	logger *MultiLogger
+	// serializes reloads
+	mu      sync.Mutex
+	current *LoggerConfig
+	modTime time.Time
+}
+
+// Build the loggers described by the JSON configuration file at path. See LoggerConfig for the
+// format.
+func LoadConfig(path string) (*Config, error) {
+	cfg, modTime, err := readConfig(path)
+	if err != nil {
+		return nil, err
+	}
+	l, err := cfg.build()
+	if err != nil {
+		return nil, err
+	}
+	return &Config{path: path, logger: l.(*MultiLogger), current: cfg, modTime: modTime}, nil
+}
+
+// Returns the top level logger
+func (c *Config) Logger() *MultiLogger {
+	return c.logger
+}
+
+// Read the configuration file again and apply the changes. Levels are changed in place. Loggers
+// whose other settings changed are replaced by new ones, and the old ones are closed once the
+// messages already queued for them have been written. If the file
+// is invalid nothing is changed.
+func (c *Config) Reload() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	cfg, modTime, err := readConfig(c.path)
+	if err != nil {
+		return err
+	}
+	err = reconcile(c.logger, c.current, cfg)
+	c.current, c.modTime = cfg, modTime
+	return err
+}
+
+// Check the modification time of the configuration file every interval and reload it when it
+// has changed. Errors are written to the loggers as *LOG* messages. Call the returned function to stop
+// watching.
+func (c *Config) Watch(interval time.Duration) (stop func()) {
+	done := make(chan bool)
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for {
+			select {
+			case <-ticker.C:
+				// a missing file is probably being replaced, try again on the next tick
+				info, err := os.Stat(c.path)
+				if err != nil {
+					continue
+				}
+				c.mu.Lock()
+				changed := !info.ModTime().Equal(c.modTime)
+				// don't retry an invalid file until it changes again
+				c.modTime = info.ModTime()
+				c.mu.Unlock()
+				if !changed {
+					continue
+				}
+				if err := c.Reload(); err != nil {
+					c.logger.Output(&Message{level: logLevel, m: fmt.Sprintf("Error reloading config: %s", err), time: time.Now()})
+				}
+			case <-done:
+				return
+			}
+		}
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() { close(done) })
+	}
+}
+
+// Read and validate a configuration file
+func readConfig(path string) (*LoggerConfig, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("Error reading config: %s", err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("Error reading config: %s", err)
+	}
+	cfg := &LoggerConfig{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(cfg); err != nil {
+		return nil, time.Time{}, fmt.Errorf("Invalid config %s: %s", path, err)
+	}
+	if cfg.Type == "" {
+		cfg.Type = "multi"
+	}
+	if cfg.Type != "multi" {
+		return nil, time.Time{}, fmt.Errorf("Invalid config %s: the top level must be a multi logger", path)
+	}
+	if err := cfg.resolve("", &LoggerConfig{}); err != nil {
+		return nil, time.Time{}, fmt.Errorf("Invalid config %s: %s", path, err)
+	}
+	return cfg, info.ModTime(), nil
+}
+
+// Check the settings and fill in defaults and inherited values
+func (cfg *LoggerConfig) resolve(name string, parent *LoggerConfig) error {
+	if cfg.Prefix == "" {
+		cfg.Prefix = parent.Prefix
+	}
+	if cfg.TimeFormat == nil {
+		cfg.TimeFormat = parent.TimeFormat
+	}
+	if cfg.Format == "" {
+		cfg.Format = parent.Format
+	}
+	if _, err := parseFormat(cfg.Format); err != nil {
+		return configError(name, "%s", err)
+	}
+	switch cfg.Type {
+	case "console":
+		if _, err := consoleOutput(cfg.Output); err != nil {
+			return configError(name, "%s", err)
+		}
+	case "file":
+		if cfg.Output == "" {
+			return configError(name, "File logger without output")
+		}
+		mode, err := parseMode(cfg.Mode)
+		if err != nil {
+			return configError(name, "%s", err)
+		}
+		if mode == ROTATE && cfg.MaxLines <= 0 {
+			return configError(name, "Rotate mode needs a positive maxLines")
+		}
+	case "multi":
+		for childName, child := range cfg.Loggers {
+			if child == nil {
+				return configError(strings.TrimPrefix(name+"."+childName, "."), "Empty logger")
+			}
+			if err := child.resolve(strings.TrimPrefix(name+"."+childName, "."), cfg); err != nil {
+				return err
+			}
+		}
+	default:
+		return configError(name, "Unknown logger type %q", cfg.Type)
+	}
+	return nil
+}
+
+// Returns the level of a console or file logger
+func (cfg *LoggerConfig) level() Level {
+	if cfg.Level != nil {
+		return *cfg.Level
+	}
+	if cfg.Type == "multi" {
+		return RegisteredLevels().Std(TRACE)
+	}
+	return RegisteredLevels().Std(INFO)
+}
+
+// Build the logger a resolved configuration describes
+func (cfg *LoggerConfig) build() (Logger, error) {
+	var l Logger
+	switch cfg.Type {
+	case "console":
+		out, _ := consoleOutput(cfg.Output)
+		l = NewBasicLogger(out, cfg.level())
+	case "file":
+		mode, _ := parseMode(cfg.Mode)
+		fl, err := NewFileLogger(cfg.Output, cfg.level(), mode, cfg.MaxLines, cfg.MaxRotate, BUFSIZE)
+		if err != nil {
+			return nil, err
+		}
+		l = fl
+	case "multi":
+		m := NewMultiLogger()
+		for _, name := range sortedNames(cfg.Loggers) {
+			child, err := cfg.Loggers[name].build()
+			if err != nil {
+				m.Close()
+				return nil, err
+			}
+			m.AddNamed(name, child, TRACE)
+		}
+		l = m
+	}
+	cfg.apply(l)
+	return l, nil
+}
+
+// Apply the settings of cfg to a logger built from it
+func (cfg *LoggerConfig) apply(l Logger) {
+	l.Level(cfg.level())
+	if cfg.Type == "multi" {
+		// the members have inherited the rest
+		return
+	}
+	l.Prefix(cfg.Prefix)
+	if cfg.TimeFormat != nil {
+		l.TimeFormat(*cfg.TimeFormat)
+	} else {
+		l.TimeFormat(TIMEFORMAT)
+	}
+	format, _ := parseFormat(cfg.Format)
+	l.Format(format)
+}
+
+// Reports whether a logger built from cfg only needs a new level to match other. File loggers
+// format messages on their own goroutine, so formatting settings aren't changed in place.
+func (cfg *LoggerConfig) sameOutput(other *LoggerConfig) bool {
+	return cfg.Type == other.Type && cfg.Output == other.Output && cfg.Mode == other.Mode &&
+		cfg.MaxLines == other.MaxLines && cfg.MaxRotate == other.MaxRotate &&
+		cfg.Prefix == other.Prefix && cfg.Format == other.Format &&
+		(cfg.TimeFormat == nil) == (other.TimeFormat == nil) &&
+		(cfg.TimeFormat == nil || *cfg.TimeFormat == *other.TimeFormat)
+}
+
+// Change the members of m, built from old, to match cfg
+func reconcile(m *MultiLogger, old, cfg *LoggerConfig) error {
+	var firstErr error
+	fail := func(err error) {
+		if firstErr == nil {
+			firstErr = err
+		}
+	}
+	for _, name := range sortedNames(old.Loggers) {
+		if _, ok := cfg.Loggers[name]; !ok {
+			m.Remove(name, true)
+		}
+	}
+	for _, name := range sortedNames(cfg.Loggers) {
+		child, oldChild := cfg.Loggers[name], old.Loggers[name]
+		member, exists := m.Get(name)
+		if exists && oldChild != nil && oldChild.sameOutput(child) {
+			if sub, ok := member.(*MultiLogger); ok {
+				if err := reconcile(sub, oldChild, child); err != nil {
+					fail(err)
+				}
+			}
+			if l, ok := member.(Logger); ok {
+				l.Level(child.level())
+			}
+			continue
+		}
+		l, err := child.build()
+		if err != nil {
+			fail(err)
+			continue
+		}
+		if exists {
+			m.Replace(name, l, true)
+		} else {
+			m.AddNamed(name, l, TRACE)
+		}
+	}
+	m.Level(cfg.level())
+	return firstErr
+}
+
+// Returns an error about the logger with the given dotted name
+func configError(name, format string, v ...interface{}) error {
+	if name == "" {
+		name = "top level"
+	}
+	return fmt.Errorf("%s: %s", name, fmt.Sprintf(format, v...))
+}
+
+func sortedNames(loggers map[string]*LoggerConfig) []string {
+	names := make([]string, 0, len(loggers))
+	for name := range loggers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func parseFormat(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "", "text":
+		return TEXT, nil
+	case "json":
+		return JSON, nil
+	}
+	return 0, fmt.Errorf("Unknown format %q", s)
+}
+
+func parseColor(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "", "auto":
+		return COLORAUTO, nil
+	case "always":
+		return COLORALWAYS, nil
+	case "never":
+		return COLORNEVER, nil
+	}
+	return 0, fmt.Errorf("Unknown color mode %q", s)
+}
+
+func parseMode(s string) (int, error) {
+	switch strings.ToLower(s) {
+	case "", "append":
+		return APPEND, nil
+	case "trunc":
+		return TRUNC, nil
+	case "backup":
+		return BACKUP, nil
+	case "rotate":
+		return ROTATE, nil
+	}
+	return 0, fmt.Errorf("Unknown mode %q", s)
+}
+
+// Returns the stream for a console logger. It is never closed, so replacing a console logger
+// doesn't close stdout.
+func consoleOutput(s string) (io.WriteCloser, error) {
+	switch strings.ToLower(s) {
+	case "", "stdout":
+		return nopCloser{os.Stdout}, nil
+	case "stderr":
+		return nopCloser{os.Stderr}, nil
+	}
+	return nil, fmt.Errorf("Unknown console output %q", s)
+}
+
+// An io.WriteCloser whose Close does nothing
+type nopCloser struct {
+	io.Writer
+}
+
+func (nopCloser) Close() error { return nil }
@@ -4,72 +4,76 @@ import (
 	"fmt"
 	"io"
 	"os"
+	"sync/atomic"
 	"time"
 )
 
 type ConsoleLogger struct {
-	out        io.WriteCloser
-	outLevel   int
-	timeFormat string
-	prefix     string
-	levels     []string
-	closed     bool
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	outLevel int64
+	formatter
+	out    io.WriteCloser
+	closed bool
 }
 
 // Create a new console logger with output level o, and an empty prefix
-func NewConsoleLogger(o int) *ConsoleLogger {
-	return &ConsoleLogger{
-		out:        os.Stdout,
-		outLevel:   o,
-		timeFormat: TIMEFORMAT,
-		prefix:     "",
-		levels:     levels,
-	}
+func NewConsoleLogger(o Level) *ConsoleLogger {
+	return NewBasicLogger(os.Stdout, o)
 }
 
-func NewBasicLogger(f io.WriteCloser, level int) *ConsoleLogger {
-	return &ConsoleLogger{
-		out:        f,
-		outLevel:   level,
-		timeFormat: TIMEFORMAT,
-		prefix:     "",
-		levels:     levels,
+// Create a new console logger writing to f. Level names are colored if f is a terminal, see
+// Color.
+func NewBasicLogger(f io.WriteCloser, level Level) *ConsoleLogger {
+	l := &ConsoleLogger{
+		formatter: newFormatter(),
+		out:       f,
+		outLevel:  int64(level),
 	}
+	l.color = colorEnabled(COLORAUTO, f)
+	return l
 }
 
 // Generic output function. If msg does not end with a newline, one will be appended.
-func (l *ConsoleLogger) output(msg *Message) {
-	buf := []byte{}
-	buf = append(buf, msg.time.Format(l.timeFormat)...)
-	if l.prefix != "" {
-		buf = append(buf, ' ')
-		buf = append(buf, l.prefix...)
-	}
-	buf = append(buf, ' ')
-	buf = append(buf, l.levels[msg.level]...)
-	buf = append(buf, ' ')
-	buf = append(buf, msg.m...)
-	if len(msg.m) > 0 && msg.m[len(msg.m)-1] != '\n' {
-		buf = append(buf, '\n')
+func (l *ConsoleLogger) Output(msg *Message) {
+	buf := l.formatMessage(msg)
+	if sw, ok := l.out.(syslogWriter); ok {
+		sw.writeSyslog(levelsOr(l.levels).Syslog(msg.level), buf)
+		return
 	}
 	l.out.Write(buf)
 }
 
-// Sets the available levels for this logger
+// Sets the names of the levels 0, 1, 2... for this logger.
+//
+// Deprecated: use Levels, which also sets the severities of the levels.
 func (l *ConsoleLogger) SetLevels(lvls []string) {
-	if lvls[len(lvls)-1] != "*LOG*" {
-		lvls = append(lvls, "*LOG*")
+	if len(lvls) > 0 && lvls[len(lvls)-1] == "*LOG*" {
+		lvls = lvls[:len(lvls)-1]
 	}
-	l.levels = lvls
+	if s, err := LevelNames(lvls...); err == nil {
+		l.levels = s
+	}
+}
+
+// Sets the levels for this logger, instead of the registered ones
+func (l *ConsoleLogger) Levels(s *LevelSet) {
+	l.levels = s
 }
 
 // Sets the output level for this logger
-func (l *ConsoleLogger) Level(o int) {
-	if o >= 0 && o <= len(l.levels)-1 {
-		l.outLevel = o
+func (l *ConsoleLogger) Level(o Level) {
+	if levelsOr(l.levels).Valid(o) {
+		atomic.StoreInt64(&l.outLevel, int64(o))
 	}
 }
 
+// Sets whether level names are colored: COLORAUTO (the default) colors them if the output is
+// a terminal and the NO_COLOR environment variable is not set, COLORALWAYS and COLORNEVER
+// override that. The colors are those of the logger's LevelSet, see LevelSet.WithColors.
+func (l *ConsoleLogger) Color(mode int) {
+	l.color = colorEnabled(mode, l.out)
+}
+
 // Sets the prefix for this logger
 func (l *ConsoleLogger) Prefix(p string) {
 	l.prefix = p
@@ -80,21 +84,57 @@ func (l *ConsoleLogger) TimeFormat(f string) {
 	l.timeFormat = f
 }
 
+// Sets the output format (TEXT or JSON) for this logger
+func (l *ConsoleLogger) Format(f int) {
+	l.format = f
+}
+
+// Sets how line breaks inside text messages are written (RAW, INDENT or ESCAPE) for this logger
+func (l *ConsoleLogger) Multiline(m int) {
+	l.multiline = m
+}
+
+// Sets the sanitization policy (STRIPANSI, ESCAPECTRL or SANITIZE for both) applied to the
+// message and prefix of every record written by this logger
+func (l *ConsoleLogger) Sanitize(policy int) {
+	l.sanitize = policy
+}
+
+// Sets the redactor that masks secrets in messages before they are formatted. Pass nil to
+// disable redaction.
+func (l *ConsoleLogger) Redact(r *Redactor) {
+	l.redactor = r
+}
+
+// Enables or disables annotating each message with the file, line and function of the caller
+func (l *ConsoleLogger) ShowCaller(b bool) {
+	l.showCaller = b
+}
+
+// Sets the minimum level at which stack traces are attached to messages. A negative level
+// disables stack traces.
+func (l *ConsoleLogger) StackTrace(lvl Level) {
+	l.stackTrace = lvl >= 0
+	l.stackLevel = lvl
+}
+
 // Close the logger
 func (l *ConsoleLogger) Close() {
 	l.closed = true
-	l.output(&Message{len(l.levels) - 1, "Closing log now", time.Now()})
+	l.Output(&Message{level: logLevel, m: "Closing log now", time: time.Now()})
 	l.out.Close()
 }
 
-func (l *ConsoleLogger) log(lvl int, format string, v ...interface{}) {
-	if lvl < l.outLevel || l.closed {
+// Log a message at the standard level lvl, mapped to the logger's levels
+func (l *ConsoleLogger) log(lvl Level, format string, v ...interface{}) {
+	lvl = levelsOr(l.levels).Std(lvl)
+	if !enabled(lvl, l.GetLevel()) || l.closed {
 		return
 	}
 	// recover in case the channel has already been closed (unlikely race condition)
 	// this could also be solved with a lock, but would cause a performance hit
 	defer recover()
-	l.output(&Message{lvl, fmt.Sprintf(format, v...), time.Now()})
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
 }
 
 // Logging functions
@@ -122,38 +162,38 @@ func (l *ConsoleLogger) Trace(format string, v ...interface{}) {
 	l.log(TRACE, format, v...)
 }
 
-func (l *ConsoleLogger) Print(lvl int, v ...interface{}) {
-	l.output(&Message{lvl, fmt.Sprint(v...), time.Now()})
+func (l *ConsoleLogger) Print(lvl Level, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprint(v...)))
 }
 
-func (l *ConsoleLogger) Printf(lvl int, format string, v ...interface{}) {
-	l.output(&Message{lvl, fmt.Sprintf(format, v...), time.Now()})
+func (l *ConsoleLogger) Printf(lvl Level, format string, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
 }
 
-func (l *ConsoleLogger) GetLevel() int {
-	return l.outLevel
+func (l *ConsoleLogger) GetLevel() Level {
+	return Level(atomic.LoadInt64(&l.outLevel))
 }
 
 func (l *ConsoleLogger) IsFatal() bool {
-	return l.outLevel <= FATAL
+	return enabled(levelsOr(l.levels).Std(FATAL), l.GetLevel())
 }
 
 func (l *ConsoleLogger) IsError() bool {
-	return l.outLevel <= ERROR
+	return enabled(levelsOr(l.levels).Std(ERROR), l.GetLevel())
 }
 
 func (l *ConsoleLogger) IsWarn() bool {
-	return l.outLevel <= WARN
+	return enabled(levelsOr(l.levels).Std(WARN), l.GetLevel())
 }
 
 func (l *ConsoleLogger) IsInfo() bool {
-	return l.outLevel <= INFO
+	return enabled(levelsOr(l.levels).Std(INFO), l.GetLevel())
 }
 
 func (l *ConsoleLogger) IsDebug() bool {
-	return l.outLevel <= DEBUG
+	return enabled(levelsOr(l.levels).Std(DEBUG), l.GetLevel())
 }
 
 func (l *ConsoleLogger) IsTrace() bool {
-	return l.outLevel <= TRACE
+	return enabled(levelsOr(l.levels).Std(TRACE), l.GetLevel())
 }
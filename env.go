@@ -0,0 +1,86 @@
+package lumber
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	// number of rotated files kept when LUMBER_ROTATE_LINES is set without LUMBER_MAX_ROTATE, and
+	// the default of -log.max-rotate
+	ENVMAXROTATE = 10
+)
+
+// Create the default logger from the environment:
+//
+//	LUMBER_LEVEL         level name, e.g. DEBUG (default INFO)
+//	LUMBER_FORMAT        TEXT or JSON
+//	LUMBER_FILE          log to this file instead of stdout, appending to it
+//	LUMBER_ROTATE_LINES  rotate the file after this many lines
+//	LUMBER_MAX_ROTATE    number of rotated files to keep (default ENVMAXROTATE)
+//	LUMBER_PREFIX        prefix for every message
+//	LUMBER_TIMEFORMAT    time format, as for TimeFormat
+//
+// Invalid values are reported on errOut and ignored.
+func loggerFromEnv(getenv func(string) string, errOut io.Writer) Logger {
+	report := func(name, format string, v ...interface{}) {
+		fmt.Fprintf(errOut, "lumber: ignoring %s=%q: %s\n", name, getenv(name), fmt.Sprintf(format, v...))
+	}
+	envInt := func(name string, def int) int {
+		s := getenv(name)
+		if s == "" {
+			return def
+		}
+		n, err := strconv.Atoi(s)
+		if err != nil || n < 0 {
+			report(name, "not a non-negative number")
+			return def
+		}
+		return n
+	}
+
+	level := RegisteredLevels().Std(INFO)
+	if s := getenv("LUMBER_LEVEL"); s != "" {
+		if lvl, err := ParseLevel(s); err != nil {
+			report("LUMBER_LEVEL", "%s", err)
+		} else {
+			level = lvl
+		}
+	}
+
+	var l Logger
+	if file := getenv("LUMBER_FILE"); file != "" {
+		mode, maxLines, maxRotate := APPEND, envInt("LUMBER_ROTATE_LINES", 0), envInt("LUMBER_MAX_ROTATE", ENVMAXROTATE)
+		if maxLines > 0 {
+			mode = ROTATE
+		}
+		fl, err := NewFileLogger(file, level, mode, maxLines, maxRotate, BUFSIZE)
+		if err != nil {
+			report("LUMBER_FILE", "%s", err)
+		} else {
+			l = fl
+		}
+	}
+	if l == nil {
+		l = NewConsoleLogger(level)
+	}
+
+	switch s := getenv("LUMBER_FORMAT"); strings.ToUpper(s) {
+	case "":
+	case "TEXT":
+		l.Format(TEXT)
+	case "JSON":
+		l.Format(JSON)
+	default:
+		report("LUMBER_FORMAT", "expected TEXT or JSON")
+	}
+	if p := getenv("LUMBER_PREFIX"); p != "" {
+		l.Prefix(p)
+	}
+	if f := getenv("LUMBER_TIMEFORMAT"); f != "" {
+		l.TimeFormat(f)
+	}
+	return l
+}
@@ -2,12 +2,14 @@ package lumber
 
 import (
 	"bufio"
+	"bytes"
 	"fmt"
 	"os"
 	"path/filepath"
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -24,13 +26,14 @@ const (
 )
 
 type FileLogger struct {
-	queue                                         chan *Message
-	done                                          chan bool
-	out                                           *os.File
-	timeFormat, prefix                            string
-	outLevel, maxLines, curLines, maxRotate, mode int
-	closed, errored                               bool
-	levels                                        []string
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	outLevel int64
+	formatter
+	queue                               chan *Message
+	done                                chan bool
+	out                                 *os.File
+	maxLines, curLines, maxRotate, mode int
+	closed, errored                     bool
 }
 
 // Convenience function to create a new append-only logger
@@ -56,7 +59,7 @@ func NewRotateLogger(f string, maxLines, maxRotate int) (*FileLogger, error) {
 // Creates a new FileLogger with filename f, output level o, and an empty prefix.
 // Modes are described in the documentation; maxLines and maxRotate are only significant
 // for some modes.
-func NewFileLogger(f string, o, mode, maxLines, maxRotate, bufsize int) (*FileLogger, error) {
+func NewFileLogger(f string, o Level, mode, maxLines, maxRotate, bufsize int) (*FileLogger, error) {
 	var file *os.File
 	var err error
 
@@ -83,22 +86,20 @@ func NewFileLogger(f string, o, mode, maxLines, maxRotate, bufsize int) (*FileLo
 	return newFileLogger(file, o, mode, maxLines, maxRotate, bufsize), nil
 }
 
-func NewBasicFileLogger(f *os.File, level int) (l *FileLogger) {
+func NewBasicFileLogger(f *os.File, level Level) (l *FileLogger) {
 	return newFileLogger(f, level, 0, 0, 0, BUFSIZE)
 }
 
-func newFileLogger(f *os.File, o, mode, maxLines, maxRotate, bufsize int) (l *FileLogger) {
+func newFileLogger(f *os.File, o Level, mode, maxLines, maxRotate, bufsize int) (l *FileLogger) {
 	l = &FileLogger{
-		queue:      make(chan *Message, bufsize),
-		done:       make(chan bool),
-		out:        f,
-		outLevel:   o,
-		timeFormat: TIMEFORMAT,
-		prefix:     "",
-		maxLines:   maxLines,
-		maxRotate:  maxRotate,
-		mode:       mode,
-		levels:     levels,
+		formatter: newFormatter(),
+		queue:     make(chan *Message, bufsize),
+		done:      make(chan bool),
+		out:       f,
+		outLevel:  int64(o),
+		maxLines:  maxLines,
+		maxRotate: maxRotate,
+		mode:      mode,
 	}
 
 	if mode == ROTATE {
@@ -115,15 +116,15 @@ func (l *FileLogger) startOutput() {
 		m, ok := <-l.queue
 		if !ok {
 			// the channel is closed and empty
-			l.printLog(&Message{len(l.levels) - 1, fmt.Sprintf("Closing log now"), time.Now()})
+			l.printLog(&Message{level: logLevel, m: "Closing log now", time: time.Now()})
 			l.out.Sync()
 			if err := l.out.Close(); err != nil {
-				l.printLog(&Message{len(l.levels) - 1, fmt.Sprintf("Error closing log file: %s", err), time.Now()})
+				l.printLog(&Message{level: logLevel, m: fmt.Sprintf("Error closing log file: %s", err), time: time.Now()})
 			}
 			l.done <- true
 			return
 		}
-		l.output(m)
+		l.write(m)
 	}
 }
 
@@ -197,16 +198,28 @@ func doRotate(f string, limit int) (*os.File, error) {
 	return os.OpenFile(f, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0644)
 }
 
-// Generic output function. Outputs messages if they are higher level than outLevel for this
-// specific logger. If msg does not end with a newline, one will be appended.
-func (l *FileLogger) output(msg *Message) {
+// Generic output function. Queues msg to be written by the output goroutine, so it is safe to
+// call from any goroutine.
+func (l *FileLogger) Output(msg *Message) {
+	if l.closed {
+		return
+	}
+	// recover in case the channel has already been closed (unlikely race condition)
+	// this could also be solved with a lock, but would cause a performance hit
+	defer recover()
+	l.queue <- msg
+}
+
+// Write msg to the file, rotating first if necessary. Only called by the output goroutine.
+// If msg does not end with a newline, one will be appended.
+func (l *FileLogger) write(msg *Message) {
 	if l.mode == ROTATE && l.curLines >= l.maxLines && !l.errored {
 		err := l.rotate()
 		if err != nil {
 			// if we can't rotate the logs, we should stop logging to prevent the log file from growing
 			// past the limit and continuously retrying the rotate operation (but log current msg first)
 			l.printLog(msg)
-			l.printLog(&Message{len(l.levels) - 1, fmt.Sprintf("Error rotating logs: %s. Closing log."), time.Now()})
+			l.printLog(&Message{level: logLevel, m: fmt.Sprintf("Error rotating logs: %s. Closing log.", err), time: time.Now()})
 			l.errored = true
 			l.close()
 		}
@@ -215,36 +228,33 @@ func (l *FileLogger) output(msg *Message) {
 }
 
 func (l *FileLogger) printLog(msg *Message) {
-	buf := []byte{}
-	buf = append(buf, msg.time.Format(l.timeFormat)...)
-	if l.prefix != "" {
-		buf = append(buf, ' ')
-		buf = append(buf, l.prefix...)
-	}
-	buf = append(buf, ' ')
-	buf = append(buf, l.levels[msg.level]...)
-	buf = append(buf, ' ')
-	buf = append(buf, msg.m...)
-	if len(msg.m) > 0 && msg.m[len(msg.m)-1] != '\n' {
-		buf = append(buf, '\n')
-	}
-	l.curLines += 1
+	buf := l.formatMessage(msg)
+	// count the lines actually written, multi-line messages and stack traces included
+	l.curLines += bytes.Count(buf, []byte{'\n'})
 	l.out.Write(buf)
 }
 
-// Sets the available levels for this logger
-// TODO: append a *LOG* level
+// Sets the names of the levels 0, 1, 2... for this logger.
+//
+// Deprecated: use Levels, which also sets the severities of the levels.
 func (l *FileLogger) SetLevels(lvls []string) {
-	if lvls[len(lvls)-1] != "*LOG*" {
-		lvls = append(lvls, "*LOG*")
+	if len(lvls) > 0 && lvls[len(lvls)-1] == "*LOG*" {
+		lvls = lvls[:len(lvls)-1]
+	}
+	if s, err := LevelNames(lvls...); err == nil {
+		l.levels = s
 	}
-	l.levels = lvls
+}
+
+// Sets the levels for this logger, instead of the registered ones
+func (l *FileLogger) Levels(s *LevelSet) {
+	l.levels = s
 }
 
 // Sets the output level for this logger
-func (l *FileLogger) Level(o int) {
-	if o >= 0 && o <= len(l.levels)-1 {
-		l.outLevel = o
+func (l *FileLogger) Level(o Level) {
+	if levelsOr(l.levels).Valid(o) {
+		atomic.StoreInt64(&l.outLevel, int64(o))
 	}
 }
 
@@ -258,6 +268,40 @@ func (l *FileLogger) TimeFormat(f string) {
 	l.timeFormat = f
 }
 
+// Sets the output format (TEXT or JSON) for this logger
+func (l *FileLogger) Format(f int) {
+	l.format = f
+}
+
+// Sets how line breaks inside text messages are written (RAW, INDENT or ESCAPE) for this logger
+func (l *FileLogger) Multiline(m int) {
+	l.multiline = m
+}
+
+// Sets the sanitization policy (STRIPANSI, ESCAPECTRL or SANITIZE for both) applied to the
+// message and prefix of every record written by this logger
+func (l *FileLogger) Sanitize(policy int) {
+	l.sanitize = policy
+}
+
+// Sets the redactor that masks secrets in messages before they are formatted. Pass nil to
+// disable redaction.
+func (l *FileLogger) Redact(r *Redactor) {
+	l.redactor = r
+}
+
+// Enables or disables annotating each message with the file, line and function of the caller
+func (l *FileLogger) ShowCaller(b bool) {
+	l.showCaller = b
+}
+
+// Sets the minimum level at which stack traces are attached to messages. A negative level
+// disables stack traces.
+func (l *FileLogger) StackTrace(lvl Level) {
+	l.stackTrace = lvl >= 0
+	l.stackLevel = lvl
+}
+
 // Flush the messages in the queue and shut down the logger.
 func (l *FileLogger) close() {
 	l.closed = true
@@ -290,14 +334,13 @@ func countLines(f *os.File) int {
 	return count
 }
 
-func (l *FileLogger) log(lvl int, format string, v ...interface{}) {
-	if lvl < l.outLevel || l.closed {
+// Log a message at the standard level lvl, mapped to the logger's levels
+func (l *FileLogger) log(lvl Level, format string, v ...interface{}) {
+	lvl = levelsOr(l.levels).Std(lvl)
+	if !enabled(lvl, l.GetLevel()) || l.closed {
 		return
 	}
-	// recover in case the channel has already been closed (unlikely race condition)
-	// this could also be solved with a lock, but would cause a performance hit
-	defer recover()
-	l.queue <- &Message{lvl, fmt.Sprintf(format, v...), time.Now()}
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
 }
 
 // Logging functions
@@ -325,38 +368,38 @@ func (l *FileLogger) Trace(format string, v ...interface{}) {
 	l.log(TRACE, format, v...)
 }
 
-func (l *FileLogger) Print(lvl int, v ...interface{}) {
-	l.output(&Message{lvl, fmt.Sprint(v...), time.Now()})
+func (l *FileLogger) Print(lvl Level, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprint(v...)))
 }
 
-func (l *FileLogger) Printf(lvl int, format string, v ...interface{}) {
-	l.output(&Message{lvl, fmt.Sprintf(format, v...), time.Now()})
+func (l *FileLogger) Printf(lvl Level, format string, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
 }
 
-func (l *FileLogger) GetLevel() int {
-	return l.outLevel
+func (l *FileLogger) GetLevel() Level {
+	return Level(atomic.LoadInt64(&l.outLevel))
 }
 
 func (l *FileLogger) IsFatal() bool {
-	return l.outLevel <= FATAL
+	return enabled(levelsOr(l.levels).Std(FATAL), l.GetLevel())
 }
 
 func (l *FileLogger) IsError() bool {
-	return l.outLevel <= ERROR
+	return enabled(levelsOr(l.levels).Std(ERROR), l.GetLevel())
 }
 
 func (l *FileLogger) IsWarn() bool {
-	return l.outLevel <= WARN
+	return enabled(levelsOr(l.levels).Std(WARN), l.GetLevel())
 }
 
 func (l *FileLogger) IsInfo() bool {
-	return l.outLevel <= INFO
+	return enabled(levelsOr(l.levels).Std(INFO), l.GetLevel())
 }
 
 func (l *FileLogger) IsDebug() bool {
-	return l.outLevel <= DEBUG
+	return enabled(levelsOr(l.levels).Std(DEBUG), l.GetLevel())
 }
 
 func (l *FileLogger) IsTrace() bool {
-	return l.outLevel <= TRACE
+	return enabled(levelsOr(l.levels).Std(TRACE), l.GetLevel())
 }
@@ -0,0 +1,62 @@
+package lumber
+
+import (
+	"flag"
+	"fmt"
+)
+
+// values of the flags added by RegisterFlags
+var logFlags = struct {
+	level                      Level
+	file, mode, format, prefix string
+	maxLines, maxRotate        int
+}{level: INFO}
+
+// Add flags for configuring a logger to fs, or to flag.CommandLine if fs is nil:
+//
+//	-log.level       level name (default INFO)
+//	-log.file        log to this file instead of stdout
+//	-log.mode        append, trunc, backup or rotate
+//	-log.max-lines   lines after which the file is rotated
+//	-log.max-rotate  number of rotated files to keep (default ENVMAXROTATE)
+//	-log.format      text or json
+//	-log.prefix      prefix for every message
+//
+// Use FromFlags to create the logger once the flags have been parsed.
+func RegisterFlags(fs *flag.FlagSet) {
+	if fs == nil {
+		fs = flag.CommandLine
+	}
+	fs.Var(&logFlags.level, "log.level", "log level: TRACE, DEBUG, INFO, WARN, ERROR or FATAL")
+	fs.StringVar(&logFlags.file, "log.file", "", "log to this file instead of stdout")
+	fs.StringVar(&logFlags.mode, "log.mode", "append", "log file mode: append, trunc, backup or rotate")
+	fs.IntVar(&logFlags.maxLines, "log.max-lines", 0, "number of lines after which the log file is rotated")
+	fs.IntVar(&logFlags.maxRotate, "log.max-rotate", ENVMAXROTATE, "number of rotated log files to keep")
+	fs.StringVar(&logFlags.format, "log.format", "text", "log format: text or json")
+	fs.StringVar(&logFlags.prefix, "log.prefix", "", "prefix for every log message")
+}
+
+// Create a logger as configured by the flags added by RegisterFlags
+func FromFlags() (Logger, error) {
+	mode, err := parseMode(logFlags.mode)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid -log.mode: %s", err)
+	}
+	format, err := parseFormat(logFlags.format)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid -log.format: %s", err)
+	}
+	if mode == ROTATE && logFlags.maxLines <= 0 {
+		return nil, fmt.Errorf("Invalid -log.max-lines: rotate mode needs a positive number of lines")
+	}
+
+	var l Logger
+	if logFlags.file == "" {
+		l = NewConsoleLogger(logFlags.level)
+	} else if l, err = NewFileLogger(logFlags.file, logFlags.level, mode, logFlags.maxLines, logFlags.maxRotate, BUFSIZE); err != nil {
+		return nil, err
+	}
+	l.Format(format)
+	l.Prefix(logFlags.prefix)
+	return l, nil
+}
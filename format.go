@@ -0,0 +1,214 @@
+package lumber
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// format constants
+	TEXT = iota
+	JSON
+)
+
+const (
+	// multi-line message constants
+	RAW = iota
+	INDENT
+	ESCAPE
+)
+
+// formatter holds the output settings shared by the built-in loggers and renders messages
+// into the bytes that get written out.
+type formatter struct {
+	capture
+	timeFormat, prefix string
+	format, multiline  int
+	sanitize           int
+	redactor           *Redactor
+	levels             *LevelSet
+	// color the level names, only ever set by ConsoleLogger
+	color bool
+}
+
+func newFormatter() formatter {
+	return formatter{
+		timeFormat: TIMEFORMAT,
+		prefix:     "",
+		format:     TEXT,
+		multiline:  RAW,
+	}
+}
+
+// Render msg in the configured format. The result always ends with a newline.
+func (f *formatter) formatMessage(msg *Message) []byte {
+	if f.redactor != nil {
+		msg = f.redactor.redactMessage(msg)
+	}
+	if f.format == JSON {
+		return f.formatJSON(msg)
+	}
+	return f.formatText(msg)
+}
+
+// Reports whether the caller of msg is rendered. Named loggers decide for themselves whether to
+// capture it.
+func (f *formatter) rendersCaller(msg *Message) bool {
+	return (f.showCaller || msg.name != "") && msg.caller != nil
+}
+
+// Reports whether the stack trace of msg is rendered
+func (f *formatter) rendersStack(msg *Message) bool {
+	return (f.wantStack(msg.level) || msg.name != "") && msg.stack != ""
+}
+
+func (f *formatter) formatText(msg *Message) []byte {
+	buf := []byte{}
+	buf = append(buf, msg.time.Format(f.timeFormat)...)
+	if f.prefix != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, sanitizeString(f.prefix, f.sanitize)...)
+	}
+	if msg.name != "" {
+		buf = append(buf, ' ')
+		buf = append(buf, sanitizeString(msg.name, f.sanitize)...)
+	}
+	buf = append(buf, ' ')
+	buf = f.appendLevel(buf, msg.level)
+	buf = append(buf, ' ')
+	if f.rendersCaller(msg) {
+		buf = append(buf, filepath.Base(msg.caller.File)...)
+		buf = append(buf, ':')
+		buf = strconv.AppendInt(buf, int64(msg.caller.Line), 10)
+		if msg.caller.Function != "" {
+			buf = append(buf, " ("...)
+			buf = append(buf, shortFuncName(msg.caller.Function)...)
+			buf = append(buf, ')')
+		}
+		buf = append(buf, ' ')
+	}
+	m := sanitizeString(strings.TrimSuffix(msg.m, "\n"), f.sanitize)
+	switch f.multiline {
+	case INDENT:
+		m = strings.Replace(m, "\n", "\n\t", -1)
+	case ESCAPE:
+		m = escapeNewlines(m)
+	}
+	buf = append(buf, m...)
+	for _, fld := range msg.fields {
+		buf = append(buf, ' ')
+		buf = append(buf, sanitizeString(fld.Key, f.sanitize)...)
+		buf = append(buf, '=')
+		buf = appendTextValue(buf, fld.Value, f.sanitize)
+	}
+	// every record ends with a newline, even an empty one
+	buf = append(buf, '\n')
+	if f.rendersStack(msg) {
+		if f.multiline == ESCAPE || f.sanitize&ESCAPECTRL != 0 {
+			// keep the stack on the same line as the message
+			buf = bytes.TrimSuffix(buf, []byte{'\n'})
+			buf = append(buf, escapeNewlines("\n"+strings.TrimSuffix(msg.stack, "\n"))...)
+			return append(buf, '\n')
+		}
+		buf = appendIndented(buf, msg.stack)
+	}
+	return buf
+}
+
+// Append a field value, quoting it if it contains spaces or characters that would make the
+// key=value pairs ambiguous
+func appendTextValue(buf []byte, v interface{}, policy int) []byte {
+	s := sanitizeString(fmt.Sprint(v), policy)
+	if s == "" || strings.ContainsAny(s, " =\"\n\r") {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// Replace line breaks with their escaped representation so s is written as a single line
+func escapeNewlines(s string) string {
+	return strings.NewReplacer("\r", `\r`, "\n", `\n`).Replace(s)
+}
+
+// Append each line of s indented by a tab
+func appendIndented(buf []byte, s string) []byte {
+	for _, line := range strings.SplitAfter(s, "\n") {
+		if line == "" {
+			continue
+		}
+		buf = append(buf, '\t')
+		buf = append(buf, line...)
+	}
+	if s[len(s)-1] != '\n' {
+		buf = append(buf, '\n')
+	}
+	return buf
+}
+
+func (f *formatter) formatJSON(msg *Message) []byte {
+	buf := []byte{'{'}
+	buf = appendJSONField(buf, "time", msg.time.Format(f.timeFormat))
+	buf = appendJSONField(buf, "level", levelsOr(f.levels).Name(msg.level))
+	// the JSON encoding already escapes control characters, so only strip escape sequences
+	if f.prefix != "" {
+		buf = appendJSONField(buf, "prefix", sanitizeString(f.prefix, f.sanitize&STRIPANSI))
+	}
+	if msg.name != "" {
+		buf = appendJSONField(buf, "logger", sanitizeString(msg.name, f.sanitize&STRIPANSI))
+	}
+	buf = appendJSONField(buf, "msg", sanitizeString(strings.TrimSuffix(msg.m, "\n"), f.sanitize&STRIPANSI))
+	if f.rendersCaller(msg) {
+		buf = appendJSONField(buf, "file", msg.caller.File)
+		buf = appendJSONField(buf, "line", msg.caller.Line)
+		if msg.caller.Function != "" {
+			buf = appendJSONField(buf, "func", msg.caller.Function)
+		}
+	}
+	if f.rendersStack(msg) {
+		buf = appendJSONField(buf, "stack", msg.stack)
+	}
+	for _, fld := range msg.fields {
+		v := fld.Value
+		if s, ok := v.(string); ok {
+			v = sanitizeString(s, f.sanitize&STRIPANSI)
+		}
+		buf = appendJSONField(buf, sanitizeString(fld.Key, f.sanitize&STRIPANSI), v)
+	}
+	buf = append(buf, '}', '\n')
+	return buf
+}
+
+func (f *formatter) levelName(lvl Level) string {
+	return levelsOr(f.levels).paddedName(lvl)
+}
+
+// Append a "key":value pair to a JSON object under construction
+func appendJSONField(buf []byte, key string, v interface{}) []byte {
+	if buf[len(buf)-1] != '{' {
+		buf = append(buf, ',')
+	}
+	k, _ := json.Marshal(key)
+	buf = append(buf, k...)
+	buf = append(buf, ':')
+	if err, ok := v.(error); ok {
+		v = err.Error()
+	}
+	val, err := json.Marshal(v)
+	if err != nil {
+		val, _ = json.Marshal(fmt.Sprint(v))
+	}
+	return append(buf, val...)
+}
+
+// Strip the import path from a fully qualified function name,
+// e.g. "github.com/user/pkg.(*T).Method" becomes "pkg.(*T).Method"
+func shortFuncName(fn string) string {
+	if i := strings.LastIndex(fn, "/"); i >= 0 {
+		return fn[i+1:]
+	}
+	return fn
+}
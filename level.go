@@ -0,0 +1,67 @@
+package lumber
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+)
+
+// Level is the severity of a message, from TRACE to FATAL unless other levels are registered. It
+// can be used as a flag.Value and is read and written as its name in text and JSON, so it can
+// appear directly in configuration.
+type Level int
+
+// Returns the level with the given name in the registered set, ignoring case and surrounding
+// space. A number is accepted as well, as long as it is a valid level.
+func ParseLevel(s string) (Level, error) {
+	return RegisteredLevels().Parse(s)
+}
+
+// Reports whether the level is in the registered set
+func (l Level) valid() bool {
+	return RegisteredLevels().Valid(l)
+}
+
+// Returns the name of the level in the registered set, or "Level(n)" if it isn't a valid level
+func (l Level) String() string {
+	if l.valid() {
+		return RegisteredLevels().Name(l)
+	}
+	return fmt.Sprintf("Level(%d)", int(l))
+}
+
+// Implements encoding.TextMarshaler
+func (l Level) MarshalText() ([]byte, error) {
+	if !l.valid() {
+		return nil, fmt.Errorf("Invalid level %d", int(l))
+	}
+	return []byte(l.String()), nil
+}
+
+// Implements encoding.TextUnmarshaler
+func (l *Level) UnmarshalText(text []byte) error {
+	lvl, err := ParseLevel(string(text))
+	if err != nil {
+		return err
+	}
+	*l = lvl
+	return nil
+}
+
+// Accepts a level name or number
+func (l *Level) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int
+		if json.Unmarshal(data, &n) != nil {
+			return fmt.Errorf("Invalid level %s", data)
+		}
+		s = strconv.Itoa(n)
+	}
+	return l.UnmarshalText([]byte(s))
+}
+
+// Implements flag.Value
+func (l *Level) Set(s string) error {
+	return l.UnmarshalText([]byte(s))
+}
@@ -0,0 +1,218 @@
+package lumber
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+// LevelHandler is an http.Handler for changing the levels of loggers at runtime. It serves the
+// loggers registered with it, all named loggers, and the default logger as "default":
+//
+//	GET /             lists all loggers and their levels
+//	GET /<name>       shows one logger
+//	PUT /<name>       sets the level from a body like {"level": "DEBUG", "revert_after": "10m"}
+//
+// With revert_after, the level that was set before is restored once the duration has passed.
+// A named logger can be given the level "INHERIT". Mount it under a prefix with http.StripPrefix.
+type LevelHandler struct {
+	mu      sync.Mutex
+	loggers map[string]Logger
+	reverts map[string]*levelRevert
+}
+
+// A pending automatic revert
+type levelRevert struct {
+	timer *time.Timer
+	at    time.Time
+	level Level
+}
+
+// A logger as shown by LevelHandler
+type levelEntry struct {
+	Name  string `json:"name"`
+	Level string `json:"level"`
+	// level set on a named logger itself, INHERIT if it uses its parent's
+	Own      string     `json:"own,omitempty"`
+	RevertAt *time.Time `json:"revert_at,omitempty"`
+	RevertTo string     `json:"revert_to,omitempty"`
+}
+
+// Body of a PUT request
+type levelRequest struct {
+	Level       string `json:"level"`
+	RevertAfter string `json:"revert_after"`
+}
+
+// Create a new LevelHandler serving the default and the named loggers
+func NewLevelHandler() *LevelHandler {
+	return &LevelHandler{
+		loggers: map[string]Logger{},
+		reverts: map[string]*levelRevert{},
+	}
+}
+
+// Serve l under the given name, in addition to the named loggers
+func (h *LevelHandler) Register(name string, l Logger) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.loggers[name] = l
+}
+
+func (h *LevelHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	name := strings.Trim(r.URL.Path, "/")
+	switch {
+	case r.Method == http.MethodGet && name == "":
+		h.writeJSON(w, h.list())
+	case r.Method == http.MethodGet:
+		if entry, ok := h.entry(name); ok {
+			h.writeJSON(w, entry)
+		} else {
+			http.Error(w, fmt.Sprintf("No logger named %q", name), http.StatusNotFound)
+		}
+	case r.Method == http.MethodPut && name != "":
+		var req levelRequest
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, fmt.Sprintf("Invalid request: %s", err), http.StatusBadRequest)
+			return
+		}
+		entry, status, err := h.set(name, req)
+		if err != nil {
+			http.Error(w, err.Error(), status)
+			return
+		}
+		h.writeJSON(w, entry)
+	default:
+		w.Header().Set("Allow", "GET, PUT")
+		http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+	}
+}
+
+func (h *LevelHandler) writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+// Returns the logger with the given name
+func (h *LevelHandler) lookup(name string) (Logger, bool) {
+	h.mu.Lock()
+	l, ok := h.loggers[name]
+	h.mu.Unlock()
+	if ok {
+		return l, true
+	}
+	if named, ok := lookupNamed(name); ok {
+		return named, true
+	}
+	if name == "default" {
+		return stdLog, true
+	}
+	return nil, false
+}
+
+// Returns all loggers, sorted by name
+func (h *LevelHandler) list() []levelEntry {
+	names := map[string]bool{"default": true}
+	h.mu.Lock()
+	for name := range h.loggers {
+		names[name] = true
+	}
+	h.mu.Unlock()
+	for _, l := range NamedLoggers() {
+		names[l.Name()] = true
+	}
+	entries := make([]levelEntry, 0, len(names))
+	for name := range names {
+		if entry, ok := h.entry(name); ok {
+			entries = append(entries, entry)
+		}
+	}
+	sort.Slice(entries, func(i, j int) bool { return entries[i].Name < entries[j].Name })
+	return entries
+}
+
+func (h *LevelHandler) entry(name string) (levelEntry, bool) {
+	l, ok := h.lookup(name)
+	if !ok {
+		return levelEntry{}, false
+	}
+	entry := levelEntry{Name: name, Level: l.GetLevel().String()}
+	if named, ok := l.(*NamedLogger); ok {
+		entry.Own = levelString(named.OwnLevel())
+	}
+	h.mu.Lock()
+	if rev, ok := h.reverts[name]; ok {
+		at := rev.at
+		entry.RevertAt = &at
+		entry.RevertTo = levelString(rev.level)
+	}
+	h.mu.Unlock()
+	return entry, true
+}
+
+// Set the level of a logger as requested, scheduling a revert if asked to
+func (h *LevelHandler) set(name string, req levelRequest) (levelEntry, int, error) {
+	l, ok := h.lookup(name)
+	if !ok {
+		return levelEntry{}, http.StatusNotFound, fmt.Errorf("No logger named %q", name)
+	}
+	named, isNamed := l.(*NamedLogger)
+	var lvl Level
+	if isNamed && strings.EqualFold(strings.TrimSpace(req.Level), "INHERIT") {
+		lvl = INHERIT
+	} else {
+		var err error
+		if lvl, err = ParseLevel(req.Level); err != nil {
+			return levelEntry{}, http.StatusBadRequest, err
+		}
+	}
+	var after time.Duration
+	if req.RevertAfter != "" {
+		var err error
+		if after, err = time.ParseDuration(req.RevertAfter); err != nil || after <= 0 {
+			return levelEntry{}, http.StatusBadRequest, fmt.Errorf("Invalid revert_after %q", req.RevertAfter)
+		}
+	}
+
+	h.mu.Lock()
+	// a pending revert still restores the level from before the first change
+	prev := l.GetLevel()
+	if isNamed {
+		prev = named.OwnLevel()
+	}
+	if rev, ok := h.reverts[name]; ok {
+		rev.timer.Stop()
+		prev = rev.level
+		delete(h.reverts, name)
+	}
+	l.Level(lvl)
+	if after > 0 {
+		rev := &levelRevert{at: time.Now().Add(after), level: prev}
+		rev.timer = time.AfterFunc(after, func() {
+			h.mu.Lock()
+			defer h.mu.Unlock()
+			// only revert if this is still the pending revert
+			if h.reverts[name] == rev {
+				l.Level(rev.level)
+				delete(h.reverts, name)
+			}
+		})
+		h.reverts[name] = rev
+	}
+	h.mu.Unlock()
+
+	entry, _ := h.entry(name)
+	return entry, http.StatusOK, nil
+}
+
+// Returns the name of lvl, or "INHERIT"
+func levelString(lvl Level) string {
+	if lvl == INHERIT {
+		return "INHERIT"
+	}
+	return lvl.String()
+}
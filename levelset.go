@@ -0,0 +1,233 @@
+package lumber
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+	"sync/atomic"
+)
+
+// level of the messages loggers write about themselves, such as "Closing log now". It is part of
+// every LevelSet and above all other levels, so these messages are always written.
+const logLevel Level = math.MaxInt32
+
+var (
+	// the registered LevelSet, see RegisterLevels
+	registry atomic.Value
+	// used until a set is registered. Initialized with the package variables, unlike registry,
+	// so the default logger can be configured with level names.
+	defaultLevels = DefaultLevels()
+)
+
+// LevelDef describes one level of a LevelSet
+type LevelDef struct {
+	// the numeric severity, higher levels are more severe
+	Level Level
+	Name  string
+	// ANSI SGR parameters used for colored output, e.g. "31" for red
+	Color string
+	// syslog severity, from 0 (emergency) to 7 (debug)
+	Syslog int
+}
+
+// LevelSet is a set of named levels. The built-in loggers use the levels of the registered set
+// for parsing, naming and coloring levels unless they are given one of their own. A LevelSet
+// can't be changed once created.
+type LevelSet struct {
+	// sorted by level, ending with *LOG*
+	defs []LevelDef
+	// the levels used by the Fatal..Trace methods and IsFatal..IsTrace, indexed by TRACE..FATAL
+	std [FATAL + 1]Level
+	// length of the longest name, for aligning text output
+	width int
+}
+
+// Returns the built-in levels, TRACE to FATAL
+func DefaultLevels() *LevelSet {
+	s, _ := NewLevelSet([]LevelDef{
+		{TRACE, "TRACE", "90", 7},
+		{DEBUG, "DEBUG", "36", 7},
+		{INFO, "INFO", "32", 6},
+		{WARN, "WARN", "33", 4},
+		{ERROR, "ERROR", "31", 3},
+		{FATAL, "FATAL", "1;31", 2},
+	}, nil)
+	return s
+}
+
+// Create a set of levels. std maps the standard levels TRACE..FATAL to the levels of the set that
+// the Fatal..Trace and IsFatal..IsTrace methods use. Standard levels missing from std map to the
+// level of the same name, or else the same value. Names are matched without regard to case.
+func NewLevelSet(defs []LevelDef, std map[Level]Level) (*LevelSet, error) {
+	s := &LevelSet{}
+	names := map[string]bool{}
+	values := map[Level]bool{}
+	for _, def := range append(defs[:len(defs):len(defs)], LevelDef{logLevel, "*LOG*", "1", 5}) {
+		name := strings.ToUpper(strings.TrimSpace(def.Name))
+		switch {
+		case name == "":
+			return nil, fmt.Errorf("Level %d has no name", int(def.Level))
+		case names[name]:
+			return nil, fmt.Errorf("Duplicate level name %q", def.Name)
+		case values[def.Level]:
+			return nil, fmt.Errorf("Duplicate level %d", int(def.Level))
+		case def.Level < 0:
+			return nil, fmt.Errorf("Level %q is negative", def.Name)
+		case def.Syslog < 0 || def.Syslog > 7:
+			return nil, fmt.Errorf("Invalid syslog severity %d for level %q", def.Syslog, def.Name)
+		}
+		names[name] = true
+		values[def.Level] = true
+		def.Name = strings.TrimSpace(def.Name)
+		s.defs = append(s.defs, def)
+		if len(def.Name) > s.width {
+			s.width = len(def.Name)
+		}
+	}
+	sort.Slice(s.defs, func(i, j int) bool { return s.defs[i].Level < s.defs[j].Level })
+	for lvl := TRACE; lvl <= FATAL; lvl++ {
+		if mapped, ok := std[lvl]; ok {
+			if !s.Valid(mapped) {
+				return nil, fmt.Errorf("%s is mapped to unknown level %d", lvl.defaultName(), int(mapped))
+			}
+			s.std[lvl] = mapped
+		} else if mapped, err := s.Parse(lvl.defaultName()); err == nil {
+			s.std[lvl] = mapped
+		} else if s.Valid(lvl) {
+			s.std[lvl] = lvl
+		} else {
+			return nil, fmt.Errorf("No level for %s", lvl.defaultName())
+		}
+	}
+	return s, nil
+}
+
+// Create a set with the given names for the levels 0, 1, 2... Colors and syslog severities are
+// taken from the built-in levels with the same values.
+func LevelNames(names ...string) (*LevelSet, error) {
+	defaults := DefaultLevels()
+	defs := make([]LevelDef, len(names))
+	for i, name := range names {
+		defs[i] = LevelDef{Level(i), name, defaults.Color(Level(i)), defaults.Syslog(Level(i))}
+	}
+	return NewLevelSet(defs, nil)
+}
+
+// Make s the registered set, used by ParseLevel, Level's String method and all loggers that
+// haven't been given a set of their own
+func RegisterLevels(s *LevelSet) {
+	registry.Store(s)
+}
+
+// Returns the registered set
+func RegisteredLevels() *LevelSet {
+	if s, ok := registry.Load().(*LevelSet); ok {
+		return s
+	}
+	return defaultLevels
+}
+
+// Returns the definitions of the levels in the set, sorted by level, without *LOG*
+func (s *LevelSet) Defs() []LevelDef {
+	return append([]LevelDef(nil), s.defs[:len(s.defs)-1]...)
+}
+
+func (s *LevelSet) def(lvl Level) (LevelDef, bool) {
+	i := sort.Search(len(s.defs), func(i int) bool { return s.defs[i].Level >= lvl })
+	if i < len(s.defs) && s.defs[i].Level == lvl {
+		return s.defs[i], true
+	}
+	return LevelDef{}, false
+}
+
+// Reports whether lvl is a level of the set
+func (s *LevelSet) Valid(lvl Level) bool {
+	_, ok := s.def(lvl)
+	return ok
+}
+
+// Returns the name of lvl, or its number if it isn't in the set
+func (s *LevelSet) Name(lvl Level) string {
+	if def, ok := s.def(lvl); ok {
+		return def.Name
+	}
+	return strconv.Itoa(int(lvl))
+}
+
+// Returns the name of lvl padded to the length of the longest name in the set
+func (s *LevelSet) paddedName(lvl Level) string {
+	name := s.Name(lvl)
+	if len(name) < s.width {
+		name += strings.Repeat(" ", s.width-len(name))
+	}
+	return name
+}
+
+// Returns the color of lvl, "" if there is none
+func (s *LevelSet) Color(lvl Level) string {
+	def, _ := s.def(lvl)
+	return def.Color
+}
+
+// Returns the syslog severity of lvl. Levels that aren't in the set get the severity of the
+// nearest level below them, or debug.
+func (s *LevelSet) Syslog(lvl Level) int {
+	sev := 7
+	for _, def := range s.defs {
+		if def.Level > lvl {
+			break
+		}
+		sev = def.Syslog
+	}
+	return sev
+}
+
+// Returns the level of the set that the standard level std (TRACE..FATAL) maps to
+func (s *LevelSet) Std(std Level) Level {
+	if std >= TRACE && std <= FATAL {
+		return s.std[std]
+	}
+	return std
+}
+
+// Returns the standard level (TRACE..FATAL) that lvl corresponds to: the highest one mapped to lvl
+// or a level below it, TRACE if there is none
+func (s *LevelSet) stdOf(lvl Level) Level {
+	std := TRACE
+	for l := DEBUG; l <= FATAL; l++ {
+		if s.std[l] <= lvl {
+			std = l
+		}
+	}
+	return std
+}
+
+// Returns the level with the given name, ignoring case and surrounding space. The number of a
+// level in the set is accepted as well.
+func (s *LevelSet) Parse(str string) (Level, error) {
+	name := strings.TrimSpace(str)
+	for _, def := range s.defs {
+		if strings.EqualFold(def.Name, name) {
+			return def.Level, nil
+		}
+	}
+	if n, err := strconv.Atoi(name); err == nil && s.Valid(Level(n)) {
+		return Level(n), nil
+	}
+	return s.defs[0].Level, fmt.Errorf("Unknown level %q", str)
+}
+
+// Returns the name of a standard level in the built-in set
+func (l Level) defaultName() string {
+	return [...]string{"TRACE", "DEBUG", "INFO", "WARN", "ERROR", "FATAL"}[l]
+}
+
+// Returns s, or the registered set if s is nil
+func levelsOr(s *LevelSet) *LevelSet {
+	if s == nil {
+		return RegisteredLevels()
+	}
+	return s
+}
@@ -4,12 +4,13 @@ Package lumber implements a simple logger that supports log levels and rotation.
 package lumber
 
 import (
-	"strings"
+	"os"
+	"runtime"
 	"time"
 )
 
 const (
-	TRACE = iota
+	TRACE Level = iota
 	DEBUG
 	INFO
 	WARN
@@ -20,12 +21,21 @@ const (
 )
 
 var (
-	stdLog     Logger = NewConsoleLogger(INFO)
-	levels            = []string{"TRACE", "DEBUG", "INFO ", "WARN ", "ERROR", "FATAL", "*LOG*"}
+	stdLog     Logger = loggerFromEnv(os.Getenv, os.Stderr)
 	timeFormat        = TIMEFORMAT
 )
 
+// A Sink receives messages. It is all a type needs to implement to be added to a MultiLogger;
+// ConsoleLogger and FileLogger are the built-in implementations. Output writes msg regardless
+// of its level, callers are expected to filter.
+type Sink interface {
+	Output(msg *Message)
+	Close()
+}
+
 type Logger interface {
+	Sink
+
 	Fatal(string, ...interface{})
 	Error(string, ...interface{})
 	Warn(string, ...interface{})
@@ -39,21 +49,81 @@ type Logger interface {
 	IsInfo() bool
 	IsDebug() bool
 	IsTrace() bool
-	GetLevel() int
+	GetLevel() Level
 
-	Print(int, ...interface{})
-	Printf(int, string, ...interface{})
-	Level(int)
+	Print(Level, ...interface{})
+	Printf(Level, string, ...interface{})
+	Level(Level)
+	Levels(*LevelSet)
 	Prefix(string)
 	TimeFormat(string)
-	Close()
-	output(msg *Message)
+	Format(int)
+	Multiline(int)
+	Sanitize(int)
+	Redact(*Redactor)
+	ShowCaller(bool)
+	StackTrace(Level)
 }
 
+// A Message is a single log record
 type Message struct {
-	level int
-	m     string
-	time  time.Time
+	level  Level
+	m      string
+	time   time.Time
+	caller *runtime.Frame
+	stack  string
+	fields []Field
+	// name of the NamedLogger that created the message
+	name string
+	// the level was checked by the code that created the message, e.g. against the level of a
+	// NamedLogger, so a MultiLogger sends it to its members whatever their own levels are
+	filtered bool
+}
+
+// A key/value pair attached to a message
+type Field struct {
+	Key   string
+	Value interface{}
+}
+
+// Create a new message with the current time, for sending to a Sink
+func NewMessage(lvl Level, text string, fields ...Field) *Message {
+	return &Message{level: lvl, m: text, time: time.Now(), fields: fields}
+}
+
+// Returns the level of the message
+func (msg *Message) Level() Level {
+	return msg.level
+}
+
+// Returns the text of the message
+func (msg *Message) Text() string {
+	return msg.m
+}
+
+// Returns the time the message was logged
+func (msg *Message) Time() time.Time {
+	return msg.time
+}
+
+// Returns the stack frame of the code that logged the message, or nil if it wasn't captured
+func (msg *Message) Caller() *runtime.Frame {
+	return msg.caller
+}
+
+// Returns the stack trace captured with the message, or an empty string
+func (msg *Message) Stack() string {
+	return msg.stack
+}
+
+// Returns the name of the NamedLogger that created the message, or an empty string
+func (msg *Message) Name() string {
+	return msg.name
+}
+
+// Returns the fields attached to the message
+func (msg *Message) Fields() []Field {
+	return msg.fields
 }
 
 // SetLogger sets a new default logger
@@ -61,26 +131,24 @@ func SetLogger(l Logger) {
 	stdLog = l
 }
 
-// Returns the string representation of the level
-func LvlStr(l int) string {
-	if l >= 0 && l <= len(levels)-1 {
-		return levels[l]
+// Returns the name of the level in the registered set, padded to the length of the longest name
+func LvlStr(l Level) string {
+	if l.valid() {
+		return RegisteredLevels().paddedName(l)
 	}
 	return ""
 }
 
-// Returns the int value of the level
-func LvlInt(s string) int {
-	for i, str := range levels {
-		if strings.TrimSpace(str) == strings.ToUpper(s) {
-			return i
-		}
-	}
-	return 0
+// Returns the level with the given name, or TRACE if there is none.
+//
+// Deprecated: use ParseLevel, which reports unknown names.
+func LvlInt(s string) Level {
+	l, _ := ParseLevel(s)
+	return l
 }
 
 // Sets the output level for the default logger
-func Level(o int) {
+func SetLevel(o Level) {
 	stdLog.Level(o)
 }
 
@@ -89,6 +157,38 @@ func TimeFormat(f string) {
 	stdLog.TimeFormat(f)
 }
 
+// Sets the output format (TEXT or JSON) for the default logger
+func Format(f int) {
+	stdLog.Format(f)
+}
+
+// Sets how line breaks inside text messages are written (RAW, INDENT or ESCAPE) for the
+// default logger
+func Multiline(m int) {
+	stdLog.Multiline(m)
+}
+
+// Sets the sanitization policy (STRIPANSI, ESCAPECTRL or SANITIZE) for the default logger
+func Sanitize(policy int) {
+	stdLog.Sanitize(policy)
+}
+
+// Sets the redactor that masks secrets in messages for the default logger
+func Redact(r *Redactor) {
+	stdLog.Redact(r)
+}
+
+// Enables or disables caller (file:line) annotation for the default logger
+func ShowCaller(b bool) {
+	stdLog.ShowCaller(b)
+}
+
+// Sets the minimum level at which the default logger attaches stack traces to messages.
+// A negative level disables stack traces.
+func StackTrace(lvl Level) {
+	stdLog.StackTrace(lvl)
+}
+
 // Close the default logger
 func Close() {
 	stdLog.Close()
@@ -124,15 +224,15 @@ func Trace(format string, v ...interface{}) {
 	stdLog.Trace(format, v...)
 }
 
-func Print(lvl int, v ...interface{}) {
+func Print(lvl Level, v ...interface{}) {
 	stdLog.Print(lvl, v...)
 }
 
-func Printf(lvl int, format string, v ...interface{}) {
+func Printf(lvl Level, format string, v ...interface{}) {
 	stdLog.Printf(lvl, format, v...)
 }
 
-func GetLevel() int {
+func GetLevel() Level {
 	return stdLog.GetLevel()
 }
 
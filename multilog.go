@@ -2,133 +2,592 @@ package lumber
 
 import (
 	"fmt"
+	"sync"
+	"sync/atomic"
 	"time"
 )
 
+// MultiLogger distributes messages to a list of members. Members are usually Loggers, but any
+// Sink can be added. Besides the members' own levels, the MultiLogger keeps a minimum level for
+// each member (its route) and a floor applying to all of them, so changing the level of the
+// MultiLogger doesn't overwrite the configuration of its members.
+//
+// Members can be added, replaced and removed while other goroutines are logging: the list of
+// routes is never modified in place, changes store a new copy that subsequent messages use.
+//
+// A panic in one member doesn't keep the others from receiving a message. In async mode (see
+// NewAsyncMultiLogger) every member also has its own queue, so a slow member doesn't hold up
+// the others; messages that don't fit in a member's queue are dropped and counted.
 type MultiLogger struct {
-	loggers []Logger
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	floor int64
+	capture
+	// holds a []*route, replaced as a whole on every change
+	routes atomic.Value
+	// serializes changes to routes
+	mu sync.Mutex
+	// size of the per-member queues, 0 for synchronous delivery
+	bufsize int
+	// only deliver to the first member accepting a message
+	first bool
+	// levels used by the logging methods, nil for the registered ones
+	levels *LevelSet
+	// masks secrets once for all members
+	redactor *Redactor
+}
+
+// A member of a MultiLogger. Routes are immutable once stored.
+type route struct {
+	name     string
+	sink     Sink
+	level    Level
+	matchers []Matcher
+	*worker
+}
+
+// Delivers messages to one member and counts the ones that were lost. In async mode it owns a
+// queue and the goroutine emptying it. Shared by all copies of a route.
+type worker struct {
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	dropped uint64
+	queue   chan *Message
+	done    chan bool
+	stopped sync.Once
+}
+
+// A Sink that only accepts messages at or above its level, like every Logger
+type leveled interface {
+	GetLevel() Level
 }
 
 func NewMultiLogger() (l *MultiLogger) {
 	return &MultiLogger{}
 }
 
-func (p *MultiLogger) AddLoggers(newLogs ...Logger) {
-	for _, l := range newLogs {
-		p.loggers = append(p.loggers, l)
+// Create a new MultiLogger that delivers messages asynchronously, through a queue of size
+// bufsize for each member
+func NewAsyncMultiLogger(bufsize int) (l *MultiLogger) {
+	return &MultiLogger{bufsize: bufsize}
+}
+
+// Create a route, starting its delivery goroutine in async mode
+func (p *MultiLogger) newRoute(name string, sink Sink, lvl Level) *route {
+	r := &route{name: name, sink: sink, level: lvl, worker: &worker{}}
+	if p.bufsize > 0 {
+		r.queue = make(chan *Message, p.bufsize)
+		r.done = make(chan bool)
+		go r.run()
 	}
+	return r
+}
+
+func (r *route) run() {
+	for msg := range r.queue {
+		r.deliver(msg)
+	}
+	r.done <- true
+}
+
+// Send msg to the member, a panic only loses this message
+func (r *route) deliver(msg *Message) {
+	defer func() {
+		if recover() != nil {
+			atomic.AddUint64(&r.dropped, 1)
+		}
+	}()
+	r.sink.Output(msg)
+}
+
+// Queue msg for the member in async mode, or deliver it right away
+func (r *route) send(msg *Message) {
+	if r.queue == nil {
+		r.deliver(msg)
+		return
+	}
+	defer func() {
+		// the route was removed and its queue closed after we took the snapshot
+		if recover() != nil {
+			atomic.AddUint64(&r.dropped, 1)
+		}
+	}()
+	select {
+	case r.queue <- msg:
+	default:
+		atomic.AddUint64(&r.dropped, 1)
+	}
+}
+
+// Wait for the queued messages to be delivered and stop the delivery goroutine
+func (r *route) stop() {
+	if r.queue == nil {
+		return
+	}
+	r.stopped.Do(func() {
+		close(r.queue)
+		<-r.done
+	})
+}
+
+// Returns the current list of routes, which must not be modified
+func (p *MultiLogger) snapshot() []*route {
+	routes, _ := p.routes.Load().([]*route)
+	return routes
+}
+
+// Apply fn to a copy of the routes and store the result, unless fn returns an error
+func (p *MultiLogger) update(fn func([]*route) ([]*route, error)) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	old := p.snapshot()
+	routes, err := fn(append(make([]*route, 0, len(old)+1), old...))
+	if err != nil {
+		return err
+	}
+	p.routes.Store(routes)
+	return nil
+}
+
+// Returns the index of the named route, or -1
+func findRoute(routes []*route, name string) int {
+	for i, r := range routes {
+		if r.name != "" && r.name == name {
+			return i
+		}
+	}
+	return -1
+}
+
+func (p *MultiLogger) AddLoggers(newLogs ...Sink) {
+	p.update(func(routes []*route) ([]*route, error) {
+		for _, l := range newLogs {
+			routes = append(routes, p.newRoute("", l, TRACE))
+		}
+		return routes, nil
+	})
+}
+
+// Add a member that can be referred to by name. Messages below lvl are not sent to it.
+func (p *MultiLogger) AddNamed(name string, l Sink, lvl Level) error {
+	return p.update(func(routes []*route) ([]*route, error) {
+		if findRoute(routes, name) >= 0 {
+			return nil, fmt.Errorf("Logger %q already exists", name)
+		}
+		return append(routes, p.newRoute(name, l, lvl)), nil
+	})
+}
+
+// Replace the named member with l, keeping its minimum level. The old member is closed
+// if close is true.
+func (p *MultiLogger) Replace(name string, l Sink, close bool) error {
+	var old *route
+	err := p.update(func(routes []*route) ([]*route, error) {
+		i := findRoute(routes, name)
+		if i < 0 {
+			return nil, fmt.Errorf("No logger named %q", name)
+		}
+		old = routes[i]
+		routes[i] = p.newRoute(name, l, old.level)
+		routes[i].matchers = old.matchers
+		return routes, nil
+	})
+	if err == nil {
+		old.stop()
+		if close {
+			old.sink.Close()
+		}
+	}
+	return err
+}
+
+// Remove the named member. It is closed if close is true.
+func (p *MultiLogger) Remove(name string, close bool) error {
+	var old *route
+	err := p.update(func(routes []*route) ([]*route, error) {
+		i := findRoute(routes, name)
+		if i < 0 {
+			return nil, fmt.Errorf("No logger named %q", name)
+		}
+		old = routes[i]
+		return append(routes[:i], routes[i+1:]...), nil
+	})
+	if err == nil {
+		old.stop()
+		if close {
+			old.sink.Close()
+		}
+	}
+	return err
+}
+
+// Returns the named member
+func (p *MultiLogger) Get(name string) (Sink, bool) {
+	routes := p.snapshot()
+	if i := findRoute(routes, name); i >= 0 {
+		return routes[i].sink, true
+	}
+	return nil, false
+}
+
+// Returns the names of all named members, in the order they were added
+func (p *MultiLogger) Names() []string {
+	names := []string{}
+	for _, r := range p.snapshot() {
+		if r.name != "" {
+			names = append(names, r.name)
+		}
+	}
+	return names
+}
+
+// Returns the number of messages the named member lost, because its queue was full or it
+// panicked
+func (p *MultiLogger) Dropped(name string) (uint64, error) {
+	routes := p.snapshot()
+	i := findRoute(routes, name)
+	if i < 0 {
+		return 0, fmt.Errorf("No logger named %q", name)
+	}
+	return atomic.LoadUint64(&routes[i].dropped), nil
+}
+
+// Returns the number of messages each member lost, named or not, in the order the members were
+// added
+func (p *MultiLogger) DroppedAll() []uint64 {
+	routes := p.snapshot()
+	dropped := make([]uint64, len(routes))
+	for i, r := range routes {
+		dropped[i] = atomic.LoadUint64(&r.dropped)
+	}
+	return dropped
 }
 
 func (p *MultiLogger) ClearLoggers() {
-	p.loggers = make([]Logger, 0)
+	var old []*route
+	p.update(func(routes []*route) ([]*route, error) {
+		old = routes
+		return make([]*route, 0), nil
+	})
+	for _, r := range old {
+		r.stop()
+	}
+}
+
+// Sets the minimum level of messages sent to the named member. The member's own level is
+// left alone.
+func (p *MultiLogger) LoggerLevel(name string, lvl Level) error {
+	return p.update(func(routes []*route) ([]*route, error) {
+		i := findRoute(routes, name)
+		if i < 0 {
+			return nil, fmt.Errorf("No logger named %q", name)
+		}
+		r := *routes[i]
+		r.level = lvl
+		routes[i] = &r
+		return routes, nil
+	})
+}
+
+// Returns the minimum level of messages sent to the named member
+func (p *MultiLogger) GetLoggerLevel(name string) (Level, error) {
+	routes := p.snapshot()
+	i := findRoute(routes, name)
+	if i < 0 {
+		return 0, fmt.Errorf("No logger named %q", name)
+	}
+	return routes[i].level, nil
+}
+
+// Reports whether messages of level lvl pass the floor and the minimum level of route r
+func (p *MultiLogger) accepts(r *route, lvl Level) bool {
+	return lvl >= Level(atomic.LoadInt64(&p.floor)) && lvl >= r.level
+}
+
+// Calls fn for every member that is a Logger
+func (p *MultiLogger) eachLogger(fn func(Logger)) {
+	for _, r := range p.snapshot() {
+		if logger, ok := r.sink.(Logger); ok {
+			fn(logger)
+		}
+	}
+}
+
+// Send a message of level lvl to all members whose route accepts it, or only the first one for a
+// router. In synchronous mode Loggers without matchers are called through fn, unless messages are
+// redacted. Other members share one message, which carries the call site details any member
+// wants; each member only renders the ones its own settings ask for.
+func (p *MultiLogger) log(lvl Level, fn func(Logger), format string, v ...interface{}) {
+	lvl = levelsOr(p.levels).Std(lvl)
+	var msg *Message
+	for _, r := range p.snapshot() {
+		if !p.accepts(r, lvl) {
+			continue
+		}
+		if l, ok := r.sink.(leveled); ok && !enabled(lvl, l.GetLevel()) {
+			continue
+		}
+		if logger, ok := r.sink.(Logger); ok && r.queue == nil && r.matchers == nil && p.redactor == nil {
+			r.call(logger, fn)
+		} else {
+			if msg == nil {
+				c := p.memberCapture(lvl)
+				msg = c.newMessage(lvl, fmt.Sprintf(format, v...))
+				if p.redactor != nil {
+					msg = p.redactor.redactMessage(msg)
+				}
+			}
+			if !r.matches(msg) {
+				continue
+			}
+			r.send(msg)
+		}
+		if p.first {
+			return
+		}
+	}
+}
+
+// Returns the capture settings of the MultiLogger combined with those of its members, so a shared
+// message of level lvl carries everything any of them shows
+func (p *MultiLogger) memberCapture(lvl Level) capture {
+	c := p.capture
+	for _, r := range p.snapshot() {
+		m, ok := r.sink.(interface{ captureSettings() capture })
+		if !ok {
+			continue
+		}
+		settings := m.captureSettings()
+		c.showCaller = c.showCaller || settings.showCaller
+		if !c.wantStack(lvl) && settings.wantStack(lvl) {
+			c.stackTrace, c.stackLevel = true, lvl
+		}
+	}
+	return c
+}
+
+// Call fn on the member, a panic only loses this message
+func (r *route) call(logger Logger, fn func(Logger)) {
+	defer func() {
+		if recover() != nil {
+			atomic.AddUint64(&r.dropped, 1)
+		}
+	}()
+	fn(logger)
 }
 
 // All of these implement the Logger interface and distribute calls to it over
 // all of the member Logger objects.
 func (p *MultiLogger) Fatal(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Fatal(s, v...)
-	}
+	p.log(FATAL, func(l Logger) { l.Fatal(s, v...) }, s, v...)
 }
 
 func (p *MultiLogger) Error(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Error(s, v...)
-	}
+	p.log(ERROR, func(l Logger) { l.Error(s, v...) }, s, v...)
 }
 
 func (p *MultiLogger) Warn(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Warn(s, v...)
-	}
+	p.log(WARN, func(l Logger) { l.Warn(s, v...) }, s, v...)
 }
 
 func (p *MultiLogger) Info(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Info(s, v...)
-	}
+	p.log(INFO, func(l Logger) { l.Info(s, v...) }, s, v...)
 }
 
 func (p *MultiLogger) Debug(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Debug(s, v...)
-	}
+	p.log(DEBUG, func(l Logger) { l.Debug(s, v...) }, s, v...)
 }
 
 func (p *MultiLogger) Trace(s string, v ...interface{}) {
-	for _, logger := range p.loggers {
-		logger.Trace(s, v...)
-	}
+	p.log(TRACE, func(l Logger) { l.Trace(s, v...) }, s, v...)
 }
 
-func (p *MultiLogger) Level(i int) {
-	for _, logger := range p.loggers {
-		logger.Level(i)
-	}
+// Sets the floor level for all members. Messages below it are not sent to any member, but the
+// levels of the members themselves are not changed.
+func (p *MultiLogger) Level(i Level) {
+	atomic.StoreInt64(&p.floor, int64(i))
+}
+
+// Sets the levels for this logger and all members
+func (p *MultiLogger) Levels(s *LevelSet) {
+	p.levels = s
+	p.eachLogger(func(logger Logger) {
+		logger.Levels(s)
+	})
 }
 
 func (p *MultiLogger) Prefix(s string) {
-	for _, logger := range p.loggers {
+	p.eachLogger(func(logger Logger) {
 		logger.Prefix(s)
-	}
+	})
 }
 
 func (p *MultiLogger) TimeFormat(s string) {
-	for _, logger := range p.loggers {
+	p.eachLogger(func(logger Logger) {
 		logger.TimeFormat(s)
-	}
+	})
+}
+
+func (p *MultiLogger) Format(f int) {
+	p.eachLogger(func(logger Logger) {
+		logger.Format(f)
+	})
+}
+
+func (p *MultiLogger) Multiline(m int) {
+	p.eachLogger(func(logger Logger) {
+		logger.Multiline(m)
+	})
+}
+
+func (p *MultiLogger) Sanitize(policy int) {
+	p.eachLogger(func(logger Logger) {
+		logger.Sanitize(policy)
+	})
 }
 
+// Sets the redactor that masks secrets in messages, nil to disable it. Messages are redacted once
+// before they are sent to the members, which keep their own redactors; don't also give r to the
+// members, or it counts every secret more than once.
+func (p *MultiLogger) Redact(r *Redactor) {
+	p.redactor = r
+}
+
+func (p *MultiLogger) ShowCaller(b bool) {
+	p.showCaller = b
+	p.eachLogger(func(logger Logger) {
+		logger.ShowCaller(b)
+	})
+}
+
+func (p *MultiLogger) StackTrace(lvl Level) {
+	p.stackTrace = lvl >= 0
+	p.stackLevel = lvl
+	p.eachLogger(func(logger Logger) {
+		logger.StackTrace(lvl)
+	})
+}
+
+// Close all members in parallel, after their queued messages have been delivered
 func (p *MultiLogger) Close() {
-	for _, logger := range p.loggers {
-		logger.Close()
+	p.CloseTimeout(0)
+}
+
+// Close all members in parallel, after their queued messages have been delivered. Returns an
+// error if they haven't all finished within d; a d of 0 waits indefinitely.
+func (p *MultiLogger) CloseTimeout(d time.Duration) error {
+	routes := p.snapshot()
+	done := make(chan bool, len(routes))
+	for _, r := range routes {
+		go func(r *route) {
+			r.stop()
+			r.sink.Close()
+			done <- true
+		}(r)
 	}
+	var timeout <-chan time.Time
+	if d > 0 {
+		timeout = time.After(d)
+	}
+	for pending := len(routes); pending > 0; pending-- {
+		select {
+		case <-done:
+		case <-timeout:
+			return fmt.Errorf("Timed out closing loggers, %d still open", pending)
+		}
+	}
+	return nil
 }
 
-func (p *MultiLogger) output(m *Message) {
-	for _, logger := range p.loggers {
-		logger.output(m)
+// Send m to every member whose route and own level accept it, or only the first one for a
+// router. *LOG* messages and messages whose level was already checked, such as those of a
+// NamedLogger with a level of its own, are sent regardless of the members' own levels.
+func (p *MultiLogger) Output(m *Message) {
+	if p.redactor != nil {
+		m = p.redactor.redactMessage(m)
+	}
+	for _, r := range p.snapshot() {
+		if !p.accepts(r, m.level) {
+			continue
+		}
+		if l, ok := r.sink.(leveled); ok && !m.filtered && m.level != logLevel && !enabled(m.level, l.GetLevel()) {
+			continue
+		}
+		if r.matches(m) {
+			r.send(m)
+			if p.first {
+				return
+			}
+		}
 	}
 }
 
-func (p *MultiLogger) Print(lvl int, v ...interface{}) {
-	p.output(&Message{lvl, fmt.Sprint(v...), time.Now()})
+func (p *MultiLogger) Print(lvl Level, v ...interface{}) {
+	p.Output(p.newMessage(lvl, fmt.Sprint(v...)))
+}
+
+func (p *MultiLogger) Printf(lvl Level, format string, v ...interface{}) {
+	p.Output(p.newMessage(lvl, fmt.Sprintf(format, v...)))
 }
 
-func (p *MultiLogger) Printf(lvl int, format string, v ...interface{}) {
-	p.output(&Message{lvl, fmt.Sprintf(format, v...), time.Now()})
+// Send a message of level lvl to the members whatever their own levels are
+func (p *MultiLogger) printFiltered(lvl Level, s string) {
+	msg := p.newMessage(lvl, s)
+	msg.filtered = true
+	p.Output(msg)
 }
 
-func (p *MultiLogger) GetLevel() int {
-	level := FATAL
-	for _, logger := range p.loggers {
-		if logger.GetLevel() <= level {
-			level = logger.GetLevel()
+// Returns the lowest level any member accepts, taking the floor and the route levels into
+// account. Members that don't have a level of their own accept everything.
+func (p *MultiLogger) GetLevel() Level {
+	level := levelsOr(p.levels).Std(FATAL)
+	for _, r := range p.snapshot() {
+		lvl := r.level
+		if l, ok := r.sink.(leveled); ok && l.GetLevel() > lvl {
+			lvl = l.GetLevel()
+		}
+		if lvl <= level {
+			level = lvl
 		}
 	}
+	if floor := Level(atomic.LoadInt64(&p.floor)); floor > level {
+		return floor
+	}
 	return level
 }
 
+// Reports whether a message of level lvl would be sent to any member, taking the vmodule
+// patterns for the calling code into account
+func (p *MultiLogger) enabled(lvl Level) bool {
+	for _, r := range p.snapshot() {
+		if !p.accepts(r, lvl) {
+			continue
+		}
+		if l, ok := r.sink.(leveled); !ok || enabled(lvl, l.GetLevel()) {
+			return true
+		}
+	}
+	return false
+}
+
 func (p *MultiLogger) IsFatal() bool {
-	return p.GetLevel() <= FATAL
+	return p.enabled(levelsOr(p.levels).Std(FATAL))
 }
 
 func (p *MultiLogger) IsError() bool {
-	return p.GetLevel() <= ERROR
+	return p.enabled(levelsOr(p.levels).Std(ERROR))
 }
 
 func (p *MultiLogger) IsWarn() bool {
-	return p.GetLevel() <= WARN
+	return p.enabled(levelsOr(p.levels).Std(WARN))
 }
 
 func (p *MultiLogger) IsInfo() bool {
-	return p.GetLevel() <= INFO
+	return p.enabled(levelsOr(p.levels).Std(INFO))
 }
 
 func (p *MultiLogger) IsDebug() bool {
-	return p.GetLevel() <= DEBUG
+	return p.enabled(levelsOr(p.levels).Std(DEBUG))
 }
 
 func (p *MultiLogger) IsTrace() bool {
-	return p.GetLevel() <= TRACE
+	return p.enabled(levelsOr(p.levels).Std(TRACE))
 }
@@ -0,0 +1,254 @@
+package lumber
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+const (
+	// level of a NamedLogger that inherits its level
+	INHERIT Level = -1
+)
+
+var (
+	namedMu sync.Mutex
+	named   = map[string]*NamedLogger{}
+)
+
+// NamedLogger is a logger identified by a dot-separated name such as "db.pool", forming a
+// hierarchy in which "db" is the parent of "db.pool". Unless its level has been set, a
+// NamedLogger uses the level of its nearest ancestor that has one, and ultimately the level of
+// the default logger. Messages are written by the default logger, with the name alongside the
+// prefix; the formatting settings of a NamedLogger therefore have no effect.
+type NamedLogger struct {
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	level int64
+	capture
+	// whether ShowCaller and StackTrace were called on this logger, the default logger's
+	// settings apply otherwise
+	ownCaller, ownStack bool
+	name                string
+	parent              *NamedLogger
+}
+
+// Returns the logger with the given name, creating it and its ancestors if necessary
+func Named(name string) *NamedLogger {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	return getNamed(name)
+}
+
+func getNamed(name string) *NamedLogger {
+	if l, ok := named[name]; ok {
+		return l
+	}
+	l := &NamedLogger{name: name, level: int64(INHERIT)}
+	if i := strings.LastIndex(name, "."); i >= 0 {
+		l.parent = getNamed(name[:i])
+	}
+	named[name] = l
+	return l
+}
+
+// Returns the logger with the given name if it has been created
+func lookupNamed(name string) (*NamedLogger, bool) {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	l, ok := named[name]
+	return l, ok
+}
+
+// Returns all named loggers that have been created, sorted by name
+func NamedLoggers() []*NamedLogger {
+	namedMu.Lock()
+	defer namedMu.Unlock()
+	loggers := make([]*NamedLogger, 0, len(named))
+	for _, l := range named {
+		loggers = append(loggers, l)
+	}
+	sort.Slice(loggers, func(i, j int) bool { return loggers[i].name < loggers[j].name })
+	return loggers
+}
+
+// Returns the name of this logger
+func (l *NamedLogger) Name() string {
+	return l.name
+}
+
+// Sets the level for this logger and the descendants that inherit it. INHERIT makes this
+// logger inherit its level again.
+func (l *NamedLogger) Level(o Level) {
+	if o == INHERIT || o.valid() {
+		atomic.StoreInt64(&l.level, int64(o))
+	}
+}
+
+// Returns the level set on this logger itself, or INHERIT
+func (l *NamedLogger) OwnLevel() Level {
+	return Level(atomic.LoadInt64(&l.level))
+}
+
+// Returns the effective level of this logger
+func (l *NamedLogger) GetLevel() Level {
+	for n := l; n != nil; n = n.parent {
+		if lvl := n.OwnLevel(); lvl != INHERIT {
+			return lvl
+		}
+	}
+	return stdLog.GetLevel()
+}
+
+// Log a message at the standard level lvl, mapped to the registered levels
+func (l *NamedLogger) log(lvl Level, format string, v ...interface{}) {
+	lvl = RegisteredLevels().Std(lvl)
+	if !enabled(lvl, l.GetLevel()) {
+		return
+	}
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
+}
+
+// Build a message, capturing call site details according to this logger's settings, or those of
+// the default logger where they haven't been set
+func (l *NamedLogger) newMessage(lvl Level, m string) *Message {
+	c := l.capture
+	if std, ok := stdLog.(interface{ captureSettings() capture }); ok {
+		def := std.captureSettings()
+		if !l.ownCaller {
+			c.showCaller = def.showCaller
+		}
+		if !l.ownStack {
+			c.stackTrace, c.stackLevel = def.stackTrace, def.stackLevel
+		}
+	}
+	return c.newMessage(lvl, m)
+}
+
+// Send msg to the default logger, labeled with this logger's name. If this logger or one of its
+// ancestors has a level, msg is marked as filtered by it, so the members of a MultiLogger default
+// logger don't filter it again by their own levels.
+func (l *NamedLogger) Output(msg *Message) {
+	if msg.name == "" {
+		msg.name = l.name
+	}
+	if l.hasLevel() {
+		msg.filtered = true
+	}
+	stdLog.Output(msg)
+}
+
+// Send a message of level lvl whatever the levels of this logger and the default logger are
+func (l *NamedLogger) printFiltered(lvl Level, s string) {
+	msg := l.newMessage(lvl, s)
+	msg.filtered = true
+	l.Output(msg)
+}
+
+// Reports whether the level of this logger or one of its ancestors has been set
+func (l *NamedLogger) hasLevel() bool {
+	for n := l; n != nil; n = n.parent {
+		if n.OwnLevel() != INHERIT {
+			return true
+		}
+	}
+	return false
+}
+
+// Has no effect, named loggers use the registered levels
+func (l *NamedLogger) Levels(s *LevelSet) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) Prefix(p string) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) TimeFormat(f string) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) Format(f int) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) Multiline(m int) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) Sanitize(policy int) {}
+
+// Has no effect, the default logger formats messages
+func (l *NamedLogger) Redact(r *Redactor) {}
+
+// Enables or disables capturing the caller of messages from this logger. Until it is called,
+// the default logger's setting applies.
+func (l *NamedLogger) ShowCaller(b bool) {
+	l.showCaller = b
+	l.ownCaller = true
+}
+
+// Sets the minimum level at which stack traces are captured for messages from this logger.
+// A negative level disables stack traces. Until it is called, the default logger's setting
+// applies.
+func (l *NamedLogger) StackTrace(lvl Level) {
+	l.stackTrace = lvl >= 0
+	l.stackLevel = lvl
+	l.ownStack = true
+}
+
+// Has no effect, the default logger is closed separately
+func (l *NamedLogger) Close() {}
+
+// Logging functions
+func (l *NamedLogger) Fatal(format string, v ...interface{}) {
+	l.log(FATAL, format, v...)
+}
+
+func (l *NamedLogger) Error(format string, v ...interface{}) {
+	l.log(ERROR, format, v...)
+}
+
+func (l *NamedLogger) Warn(format string, v ...interface{}) {
+	l.log(WARN, format, v...)
+}
+
+func (l *NamedLogger) Info(format string, v ...interface{}) {
+	l.log(INFO, format, v...)
+}
+
+func (l *NamedLogger) Debug(format string, v ...interface{}) {
+	l.log(DEBUG, format, v...)
+}
+
+func (l *NamedLogger) Trace(format string, v ...interface{}) {
+	l.log(TRACE, format, v...)
+}
+
+func (l *NamedLogger) Print(lvl Level, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprint(v...)))
+}
+
+func (l *NamedLogger) Printf(lvl Level, format string, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
+}
+
+func (l *NamedLogger) IsFatal() bool {
+	return enabled(RegisteredLevels().Std(FATAL), l.GetLevel())
+}
+
+func (l *NamedLogger) IsError() bool {
+	return enabled(RegisteredLevels().Std(ERROR), l.GetLevel())
+}
+
+func (l *NamedLogger) IsWarn() bool {
+	return enabled(RegisteredLevels().Std(WARN), l.GetLevel())
+}
+
+func (l *NamedLogger) IsInfo() bool {
+	return enabled(RegisteredLevels().Std(INFO), l.GetLevel())
+}
+
+func (l *NamedLogger) IsDebug() bool {
+	return enabled(RegisteredLevels().Std(DEBUG), l.GetLevel())
+}
+
+func (l *NamedLogger) IsTrace() bool {
+	return enabled(RegisteredLevels().Std(TRACE), l.GetLevel())
+}
@@ -0,0 +1,131 @@
+package lumber
+
+import (
+	"fmt"
+	"net"
+	"sync"
+	"time"
+)
+
+const (
+	// timeout for connecting and writing to the collector of a tcp:// or udp:// logger
+	NETTIMEOUT = 5 * time.Second
+)
+
+// netWriter sends log output to a TCP or UDP address from its own goroutine, so a slow or
+// unreachable collector doesn't block logging. Output that doesn't fit in the queue is dropped.
+// After a failed write the address is dialed again and the write retried once; while the
+// collector can't be reached, output is dropped without waiting for it.
+type netWriter struct {
+	network, addr string
+	queue         chan []byte
+	done          chan bool
+	// protects conn, closed and retryAt
+	mu     sync.Mutex
+	conn   net.Conn
+	closed bool
+	// no dialing until then, after a failed attempt or once Close gave up
+	retryAt time.Time
+}
+
+// Connect to addr and start the goroutine writing to it, with a queue of bufsize writes
+func dialNet(network, addr string, bufsize int) (*netWriter, error) {
+	conn, err := net.DialTimeout(network, addr, NETTIMEOUT)
+	if err != nil {
+		return nil, err
+	}
+	w := &netWriter{
+		network: network,
+		addr:    addr,
+		queue:   make(chan []byte, bufsize),
+		done:    make(chan bool),
+		conn:    conn,
+	}
+	go w.run()
+	return w, nil
+}
+
+// Queue p to be sent, or drop it if the queue is full. It never blocks.
+func (w *netWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if !w.closed {
+		select {
+		// the caller may reuse p
+		case w.queue <- append([]byte(nil), p...):
+		default:
+		}
+	}
+	return len(p), nil
+}
+
+func (w *netWriter) run() {
+	for p := range w.queue {
+		if w.send(p) != nil {
+			w.send(p)
+		}
+	}
+	w.mu.Lock()
+	if w.conn != nil {
+		w.conn.Close()
+	}
+	w.mu.Unlock()
+	close(w.done)
+}
+
+// Write p to the connection, dialing first if there is none
+func (w *netWriter) send(p []byte) error {
+	w.mu.Lock()
+	conn, retryAt := w.conn, w.retryAt
+	w.mu.Unlock()
+	if conn == nil {
+		if time.Now().Before(retryAt) {
+			return fmt.Errorf("Not connected to %s", w.addr)
+		}
+		var err error
+		conn, err = net.DialTimeout(w.network, w.addr, NETTIMEOUT)
+		w.mu.Lock()
+		if err != nil {
+			w.retryAt = time.Now().Add(time.Second)
+		}
+		w.conn = conn
+		w.mu.Unlock()
+		if err != nil {
+			return err
+		}
+	}
+	conn.SetWriteDeadline(time.Now().Add(NETTIMEOUT))
+	_, err := conn.Write(p)
+	if err != nil {
+		conn.Close()
+		w.mu.Lock()
+		w.conn = nil
+		w.mu.Unlock()
+	}
+	return err
+}
+
+// Send the queued output and close the connection. If the collector doesn't take the output
+// within NETTIMEOUT, the rest of it is dropped.
+func (w *netWriter) Close() error {
+	w.mu.Lock()
+	if !w.closed {
+		w.closed = true
+		close(w.queue)
+	}
+	w.mu.Unlock()
+	select {
+	case <-w.done:
+		return nil
+	case <-time.After(NETTIMEOUT):
+	}
+	// unblock the current write, the remaining ones fail right away
+	w.mu.Lock()
+	w.retryAt = time.Now().Add(NETTIMEOUT)
+	if w.conn != nil {
+		w.conn.Close()
+	}
+	w.mu.Unlock()
+	<-w.done
+	return nil
+}
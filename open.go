@@ -0,0 +1,199 @@
+package lumber
+
+import (
+	"fmt"
+	"net/url"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// a comma followed by the scheme of the next URL in a list
+var urlSeparator = regexp.MustCompile(`,\s*[A-Za-z][A-Za-z0-9+.\-]*:`)
+
+// Create a logger from a URL, or a MultiLogger from a comma-separated list of them:
+//
+//	file:///var/log/app.log?mode=rotate&maxLines=5000&maxRotate=9
+//	console://stderr                 stdout if no stream is given
+//	syslog:///dev/log                or syslog:// for the local syslog, syslog://host:514 for UDP
+//	tcp://host:514?format=json       also udp://
+//
+// All kinds accept the parameters level, format (text or json), prefix and timeFormat; file
+// loggers also accept mode (append, trunc, backup or rotate), maxLines and maxRotate, console
+// loggers color (auto, always or never), syslog loggers network (for a remote syslog) and tag
+// (default the program name). The members of a MultiLogger are named by their URLs. The list is
+// only split at commas followed by a scheme, so query values may contain commas.
+//
+// TCP and UDP loggers send messages from a queue of BUFSIZE, so a slow collector doesn't block
+// logging; messages that don't fit are dropped. After a failed write they reconnect.
+func Open(urls string) (Logger, error) {
+	list := splitURLs(urls)
+	if len(list) == 1 {
+		return openURL(strings.TrimSpace(list[0]))
+	}
+	m := NewMultiLogger()
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		l, err := openURL(s)
+		if err == nil {
+			err = m.AddNamed(s, l, TRACE)
+		}
+		if err != nil {
+			m.Close()
+			return nil, err
+		}
+	}
+	return m, nil
+}
+
+// Split a comma-separated list of URLs at the commas that start a new URL
+func splitURLs(urls string) []string {
+	var list []string
+	start := 0
+	for _, loc := range urlSeparator.FindAllStringIndex(urls, -1) {
+		list = append(list, urls[start:loc[0]])
+		start = loc[0] + 1
+	}
+	return append(list, urls[start:])
+}
+
+func openURL(s string) (Logger, error) {
+	u, err := url.Parse(s)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid logger URL %q: %s", s, err)
+	}
+	l, err := openParsedURL(u)
+	if err != nil {
+		return nil, fmt.Errorf("Invalid logger URL %q: %s", s, err)
+	}
+	return l, nil
+}
+
+func openParsedURL(u *url.URL) (Logger, error) {
+	q := u.Query()
+	allowed := map[string]bool{"level": true, "format": true, "prefix": true, "timeFormat": true}
+	param := func(name string) (int, error) {
+		if q.Get(name) == "" {
+			return 0, nil
+		}
+		n, err := strconv.Atoi(q.Get(name))
+		if err != nil || n < 0 {
+			return 0, fmt.Errorf("%s must be a non-negative number", name)
+		}
+		return n, nil
+	}
+
+	level := RegisteredLevels().Std(INFO)
+	if s := q.Get("level"); s != "" {
+		lvl, err := ParseLevel(s)
+		if err != nil {
+			return nil, err
+		}
+		level = lvl
+	}
+	format, err := parseFormat(q.Get("format"))
+	if err != nil {
+		return nil, err
+	}
+
+	var l Logger
+	switch u.Scheme {
+	case "file":
+		for _, name := range []string{"mode", "maxLines", "maxRotate"} {
+			allowed[name] = true
+		}
+		path := u.Host + u.Path
+		if u.Opaque != "" {
+			path = u.Opaque
+		}
+		if path == "" {
+			return nil, fmt.Errorf("No file name")
+		}
+		mode, err := parseMode(q.Get("mode"))
+		if err != nil {
+			return nil, err
+		}
+		maxLines, err := param("maxLines")
+		if err != nil {
+			return nil, err
+		}
+		if mode == ROTATE && maxLines == 0 {
+			return nil, fmt.Errorf("Rotate mode needs a positive maxLines")
+		}
+		maxRotate, err := param("maxRotate")
+		if err != nil {
+			return nil, err
+		}
+		if err := checkParams(q, allowed); err != nil {
+			return nil, err
+		}
+		if l, err = NewFileLogger(path, level, mode, maxLines, maxRotate, BUFSIZE); err != nil {
+			return nil, err
+		}
+	case "console":
+		allowed["color"] = true
+		if err := checkParams(q, allowed); err != nil {
+			return nil, err
+		}
+		color, err := parseColor(q.Get("color"))
+		if err != nil {
+			return nil, err
+		}
+		out, err := consoleOutput(u.Host + strings.TrimPrefix(u.Path, "/"))
+		if err != nil {
+			return nil, err
+		}
+		cl := NewBasicLogger(out, level)
+		cl.Color(color)
+		l = cl
+	case "syslog":
+		allowed["network"], allowed["tag"] = true, true
+		if err := checkParams(q, allowed); err != nil {
+			return nil, err
+		}
+		network, addr := q.Get("network"), u.Host
+		switch {
+		case u.Host == "" && u.Path != "":
+			network, addr = "unixgram", u.Path
+		case u.Host != "" && network == "":
+			network = "udp"
+		}
+		out, err := dialSyslog(network, addr, q.Get("tag"))
+		if err != nil {
+			return nil, err
+		}
+		l = NewBasicLogger(out, level)
+		// syslog adds the time itself
+		l.TimeFormat("")
+	case "tcp", "udp":
+		if err := checkParams(q, allowed); err != nil {
+			return nil, err
+		}
+		out, err := dialNet(u.Scheme, u.Host, BUFSIZE)
+		if err != nil {
+			return nil, err
+		}
+		l = NewBasicLogger(out, level)
+	default:
+		return nil, fmt.Errorf("Unknown scheme %q", u.Scheme)
+	}
+
+	l.Format(format)
+	if p := q.Get("prefix"); p != "" {
+		l.Prefix(p)
+	}
+	if _, ok := q["timeFormat"]; ok {
+		l.TimeFormat(q.Get("timeFormat"))
+	}
+	return l, nil
+}
+
+// Returns an error for the first parameter that isn't allowed
+func checkParams(q url.Values, allowed map[string]bool) error {
+	for name := range q {
+		if !allowed[name] {
+			return fmt.Errorf("Unknown parameter %q", name)
+		}
+	}
+	return nil
+}
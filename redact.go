@@ -0,0 +1,143 @@
+package lumber
+
+import (
+	"regexp"
+	"strings"
+	"sync/atomic"
+)
+
+const (
+	// replacement for redacted values
+	REDACTED = "[REDACTED]"
+)
+
+// key names whose values are redacted by default
+var defaultRedactKeys = []string{"password", "token", "authorization"}
+
+// A Redactor masks secrets in messages before they are formatted. Values are masked when they
+// follow a sensitive key name (e.g. "password=hunter2" or `"token": "abc"`), when they are
+// attached as a field under such a key, or when they match a content pattern such as a bearer
+// token or a credit card number. A Redactor may be shared by several loggers; it should be
+// fully configured before it is attached to a logger.
+type Redactor struct {
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	count      uint64
+	keys       []string
+	keyPattern *regexp.Regexp
+	rules      []redactRule
+}
+
+type redactRule struct {
+	re *regexp.Regexp
+	// optional check on a match, e.g. a checksum, to avoid masking look-alikes
+	valid func(string) bool
+}
+
+// Create a new Redactor that masks the default keys (password, token, authorization) and
+// bearer tokens, AWS access key IDs, credit card numbers and email addresses
+func NewRedactor() *Redactor {
+	r := &Redactor{
+		rules: []redactRule{
+			{regexp.MustCompile(`(?i)\bbearer\s+[A-Za-z0-9\-._~+/]+=*`), nil},
+			{regexp.MustCompile(`\b(?:AKIA|ASIA)[0-9A-Z]{16}\b`), nil},
+			{regexp.MustCompile(`\b\d(?:[ -]?\d){12,18}\b`), luhnValid},
+			{regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`), nil},
+		},
+	}
+	r.AddKeys(defaultRedactKeys...)
+	return r
+}
+
+// Mask the values of the given key names, in addition to the ones already configured.
+// Matching is case-insensitive and also applies to longer keys containing the name,
+// e.g. "db_password" or "X-Auth-Token".
+func (r *Redactor) AddKeys(keys ...string) {
+	r.keys = append(r.keys, keys...)
+	quoted := make([]string, len(r.keys))
+	for i, k := range r.keys {
+		quoted[i] = regexp.QuoteMeta(k)
+	}
+	r.keyPattern = regexp.MustCompile(`(?i)(\b[\w.\-]*(?:` + strings.Join(quoted, "|") +
+		`)[\w.\-]*"?\s*[:=]\s*"?)(?:(?:bearer|basic)\s+)?[^\s"',;&]+`)
+}
+
+// Mask every match of the pattern, in addition to the ones already configured
+func (r *Redactor) AddPattern(re *regexp.Regexp) {
+	r.rules = append(r.rules, redactRule{re, nil})
+}
+
+// Returns the number of values redacted so far
+func (r *Redactor) Count() uint64 {
+	return atomic.LoadUint64(&r.count)
+}
+
+// Mask all secrets in s
+func (r *Redactor) Redact(s string) string {
+	var n uint64
+	if r.keyPattern != nil {
+		s = r.keyPattern.ReplaceAllStringFunc(s, func(match string) string {
+			n++
+			return r.keyPattern.FindStringSubmatch(match)[1] + REDACTED
+		})
+	}
+	for _, rule := range r.rules {
+		s = rule.re.ReplaceAllStringFunc(s, func(match string) string {
+			if rule.valid != nil && !rule.valid(match) {
+				return match
+			}
+			n++
+			return REDACTED
+		})
+	}
+	if n > 0 {
+		atomic.AddUint64(&r.count, n)
+	}
+	return s
+}
+
+// Returns a copy of msg with all secrets masked, the message may be shared with other loggers
+func (r *Redactor) redactMessage(msg *Message) *Message {
+	redacted := *msg
+	redacted.m = r.Redact(msg.m)
+	redacted.fields = make([]Field, len(msg.fields))
+	for i, fld := range msg.fields {
+		redacted.fields[i] = Field{fld.Key, r.redactField(fld.Key, fld.Value)}
+	}
+	return &redacted
+}
+
+// Mask a field value entirely if its key is sensitive, otherwise mask any secrets in it
+func (r *Redactor) redactField(key string, v interface{}) interface{} {
+	lower := strings.ToLower(key)
+	for _, k := range r.keys {
+		if strings.Contains(lower, strings.ToLower(k)) {
+			atomic.AddUint64(&r.count, 1)
+			return REDACTED
+		}
+	}
+	if s, ok := v.(string); ok {
+		return r.Redact(s)
+	}
+	return v
+}
+
+// Reports whether the digits in s pass the Luhn checksum used by payment card numbers
+func luhnValid(s string) bool {
+	sum, double := 0, false
+	for i := len(s) - 1; i >= 0; i-- {
+		c := s[i]
+		if c < '0' || c > '9' {
+			continue
+		}
+		d := int(c - '0')
+		if double {
+			d *= 2
+			if d > 9 {
+				d -= 9
+			}
+		}
+		sum += d
+		double = !double
+	}
+	return sum%10 == 0
+}
@@ -0,0 +1,66 @@
+package lumber
+
+import (
+	"fmt"
+	"strings"
+)
+
+// A Matcher decides whether a message is sent to a member of a MultiLogger
+type Matcher func(msg *Message) bool
+
+// Create a new MultiLogger that sends each message to members depending on their matchers (see
+// AddRoute). If first is true, a message only goes to the first member, in the order they were
+// added, that accepts it; otherwise it goes to every member that accepts it.
+func NewRouter(first bool) (l *MultiLogger) {
+	return &MultiLogger{first: first}
+}
+
+// Add a member that only receives messages accepted by all of the matchers. Members added without
+// matchers accept everything at or above their level, so they can serve as a catch-all.
+func (p *MultiLogger) AddRoute(name string, l Sink, matchers ...Matcher) error {
+	return p.update(func(routes []*route) ([]*route, error) {
+		if name != "" && findRoute(routes, name) >= 0 {
+			return nil, fmt.Errorf("Logger %q already exists", name)
+		}
+		r := p.newRoute(name, l, TRACE)
+		r.matchers = matchers
+		return append(routes, r), nil
+	})
+}
+
+// Reports whether msg is accepted by all matchers of the route
+func (r *route) matches(msg *Message) bool {
+	for _, match := range r.matchers {
+		if !match(msg) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches messages with a level from min to max, inclusive
+func LevelRange(min, max Level) Matcher {
+	return func(msg *Message) bool {
+		return msg.level >= min && msg.level <= max
+	}
+}
+
+// Matches messages whose text starts with prefix
+func TextPrefix(prefix string) Matcher {
+	return func(msg *Message) bool {
+		return strings.HasPrefix(msg.m, prefix)
+	}
+}
+
+// Matches messages with a field named key whose value satisfies pred. A nil pred only checks
+// that the field exists.
+func FieldMatch(key string, pred func(value interface{}) bool) Matcher {
+	return func(msg *Message) bool {
+		for _, fld := range msg.fields {
+			if fld.Key == key && (pred == nil || pred(fld.Value)) {
+				return true
+			}
+		}
+		return false
+	}
+}
@@ -0,0 +1,96 @@
+package lumber
+
+import (
+	"regexp"
+	"strconv"
+	"unicode/utf8"
+)
+
+const (
+	// sanitization flags, combine with |
+	STRIPANSI = 1 << iota
+	ESCAPECTRL
+	SANITIZE = STRIPANSI | ESCAPECTRL
+)
+
+// matches CSI sequences (colors, cursor movement), OSC sequences (window titles, hyperlinks)
+// and the remaining two-byte escape sequences
+var ansiPattern = regexp.MustCompile("\x1b\\[[0-?]*[ -/]*[@-~]|\u009b[0-?]*[ -/]*[@-~]|\x1b\\][^\x07\x1b]*(\x07|\x1b\\\\)|\x1b[@-Z\\\\-_]")
+
+// Apply the sanitization policy to s. STRIPANSI removes terminal escape sequences and
+// ESCAPECTRL replaces control characters, line breaks and invalid UTF-8 with escapes, so
+// untrusted input can neither forge log lines nor take over an operator's terminal.
+func sanitizeString(s string, policy int) string {
+	if policy&STRIPANSI != 0 {
+		s = ansiPattern.ReplaceAllString(s, "")
+	}
+	if policy&ESCAPECTRL != 0 {
+		s = escapeControl(s)
+	}
+	return s
+}
+
+// Replace every unsafe character in s with an escape sequence. Tabs are left alone.
+func escapeControl(s string) string {
+	i := 0
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		if isUnsafe(r, size) {
+			break
+		}
+		i += size
+	}
+	if i == len(s) {
+		// nothing to escape
+		return s
+	}
+	buf := []byte(s[:i])
+	for i < len(s) {
+		r, size := utf8.DecodeRuneInString(s[i:])
+		switch {
+		case !isUnsafe(r, size):
+			buf = append(buf, s[i:i+size]...)
+		case r == '\n':
+			buf = append(buf, `\n`...)
+		case r == '\r':
+			buf = append(buf, `\r`...)
+		case r == utf8.RuneError || r < utf8.RuneSelf:
+			buf = append(buf, `\x`...)
+			buf = appendHex(buf, uint64(s[i]), 2)
+		default:
+			buf = append(buf, `\u`...)
+			buf = appendHex(buf, uint64(r), 4)
+		}
+		i += size
+	}
+	return string(buf)
+}
+
+// Reports whether the rune r (encoded in size bytes) could break up a log line or be
+// interpreted by a terminal
+func isUnsafe(r rune, size int) bool {
+	switch {
+	case r == '\t':
+		return false
+	case r < 0x20, r == 0x7f:
+		return true
+	case r == utf8.RuneError && size == 1:
+		// invalid UTF-8
+		return true
+	case r >= 0x80 && r < 0xa0:
+		// C1 control characters
+		return true
+	case r == '\u2028', r == '\u2029':
+		// unicode line and paragraph separators
+		return true
+	}
+	return false
+}
+
+func appendHex(buf []byte, v uint64, width int) []byte {
+	h := strconv.FormatUint(v, 16)
+	for i := len(h); i < width; i++ {
+		buf = append(buf, '0')
+	}
+	return append(buf, h...)
+}
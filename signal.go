@@ -0,0 +1,41 @@
+package lumber
+
+import (
+	"fmt"
+	"time"
+)
+
+// Move the level of each logger, or the default logger if there are none, one step down (more
+// verbose) or up in the registered levels, announcing the change with a *LOG* message. Loggers
+// whose level doesn't actually change, such as a MultiLogger whose members are less verbose
+// than the new floor, are left alone.
+func stepLevels(loggers []Logger, verbose bool) {
+	if len(loggers) == 0 {
+		loggers = []Logger{stdLog}
+	}
+	defs := RegisteredLevels().Defs()
+	for _, l := range loggers {
+		cur, next := l.GetLevel(), l.GetLevel()
+		if verbose {
+			for _, def := range defs {
+				if def.Level < cur {
+					next = def.Level
+				}
+			}
+		} else {
+			for i := len(defs) - 1; i >= 0; i-- {
+				if defs[i].Level > cur {
+					next = defs[i].Level
+				}
+			}
+		}
+		if next == cur {
+			continue
+		}
+		l.Level(next)
+		if next = l.GetLevel(); next == cur {
+			continue
+		}
+		l.Output(&Message{level: logLevel, m: fmt.Sprintf("Level changed from %s to %s", cur, next), time: time.Now()})
+	}
+}
@@ -0,0 +1,8 @@
+//go:build !unix
+
+package lumber
+
+// SIGUSR1 and SIGUSR2 don't exist on this platform, so this does nothing
+func HandleLevelSignals(loggers ...Logger) (stop func()) {
+	return func() {}
+}
@@ -0,0 +1,34 @@
+//go:build unix
+
+package lumber
+
+import (
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Make SIGUSR1 lower the level of the given loggers one step, so they write more, and SIGUSR2
+// raise it one step. Without loggers, the default logger is changed. Every change is announced
+// with a *LOG* message. The level of a MultiLogger is only a floor for its members, so it can be
+// made less verbose and back, but not more verbose than its members; pass the members to step
+// them instead. Call the returned function to stop handling the signals.
+func HandleLevelSignals(loggers ...Logger) (stop func()) {
+	ch := make(chan os.Signal, 1)
+	done := make(chan bool)
+	signal.Notify(ch, syscall.SIGUSR1, syscall.SIGUSR2)
+	go func() {
+		for {
+			select {
+			case sig := <-ch:
+				stepLevels(loggers, sig == syscall.SIGUSR1)
+			case <-done:
+				return
+			}
+		}
+	}()
+	return func() {
+		signal.Stop(ch)
+		close(done)
+	}
+}
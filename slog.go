@@ -0,0 +1,309 @@
+//go:build go1.21
+
+package lumber
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"runtime"
+	"sync/atomic"
+	"time"
+)
+
+// Returns the slog level corresponding to a level of the registered set. Levels between the ones
+// the standard levels map to are rounded down.
+func ToSlogLevel(lvl Level) slog.Level {
+	return toSlogLevel(RegisteredLevels(), lvl)
+}
+
+// Returns the level of the registered set corresponding to a slog level. Levels between the
+// standard slog levels are rounded down, levels above ERROR map to FATAL.
+func FromSlogLevel(lvl slog.Level) Level {
+	return fromSlogLevel(RegisteredLevels(), lvl)
+}
+
+func toSlogLevel(s *LevelSet, lvl Level) slog.Level {
+	if lvl == logLevel {
+		// *LOG* messages are always written
+		return slog.LevelError + 8
+	}
+	switch s.stdOf(lvl) {
+	case TRACE:
+		return slog.LevelDebug - 4
+	case DEBUG:
+		return slog.LevelDebug
+	case INFO:
+		return slog.LevelInfo
+	case WARN:
+		return slog.LevelWarn
+	case ERROR:
+		return slog.LevelError
+	}
+	return slog.LevelError + 4
+}
+
+func fromSlogLevel(s *LevelSet, lvl slog.Level) Level {
+	switch {
+	case lvl < slog.LevelDebug:
+		return s.Std(TRACE)
+	case lvl < slog.LevelInfo:
+		return s.Std(DEBUG)
+	case lvl < slog.LevelWarn:
+		return s.Std(INFO)
+	case lvl < slog.LevelError:
+		return s.Std(WARN)
+	case lvl < slog.LevelError+4:
+		return s.Std(ERROR)
+	}
+	return s.Std(FATAL)
+}
+
+// SlogHandler is a slog.Handler that writes records through a lumber Logger. Attributes and
+// groups become fields of the message, with group names joined to keys by dots.
+type SlogHandler struct {
+	logger Logger
+	fields []Field
+	group  string
+}
+
+// Create a new slog.Handler writing to l. l's level decides which records are enabled.
+func NewSlogHandler(l Logger) *SlogHandler {
+	return &SlogHandler{logger: l}
+}
+
+func (h *SlogHandler) Enabled(_ context.Context, lvl slog.Level) bool {
+	return FromSlogLevel(lvl) >= h.logger.GetLevel()
+}
+
+func (h *SlogHandler) Handle(_ context.Context, r slog.Record) error {
+	msg := &Message{level: FromSlogLevel(r.Level), m: r.Message, time: r.Time}
+	if msg.time.IsZero() {
+		msg.time = time.Now()
+	}
+	if r.PC != 0 {
+		frame, _ := runtime.CallersFrames([]uintptr{r.PC}).Next()
+		msg.caller = &frame
+	}
+	msg.fields = append(msg.fields, h.fields...)
+	r.Attrs(func(a slog.Attr) bool {
+		msg.fields = appendAttr(msg.fields, h.group, a)
+		return true
+	})
+	h.logger.Output(msg)
+	return nil
+}
+
+func (h *SlogHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	h2 := *h
+	h2.fields = make([]Field, len(h.fields), len(h.fields)+len(attrs))
+	copy(h2.fields, h.fields)
+	for _, a := range attrs {
+		h2.fields = appendAttr(h2.fields, h.group, a)
+	}
+	return &h2
+}
+
+func (h *SlogHandler) WithGroup(name string) slog.Handler {
+	if name == "" {
+		return h
+	}
+	h2 := *h
+	h2.group = h.group + name + "."
+	return &h2
+}
+
+// Flatten an attribute into fields, prefixing keys with the enclosing group names
+func appendAttr(fields []Field, group string, a slog.Attr) []Field {
+	a.Value = a.Value.Resolve()
+	if a.Equal(slog.Attr{}) {
+		return fields
+	}
+	if a.Value.Kind() == slog.KindGroup {
+		if a.Key != "" {
+			group += a.Key + "."
+		}
+		for _, ga := range a.Value.Group() {
+			fields = appendAttr(fields, group, ga)
+		}
+		return fields
+	}
+	return append(fields, Field{group + a.Key, a.Value.Any()})
+}
+
+// SlogLogger is a Logger that sends messages to a slog.Handler. The handler is responsible for
+// formatting, so the format settings of the Logger interface have no effect.
+type SlogLogger struct {
+	// first, so it is 64-bit aligned for atomic access on 32-bit platforms
+	outLevel int64
+	capture
+	handler  slog.Handler
+	prefix   string
+	redactor *Redactor
+	levels   *LevelSet
+	closed   bool
+}
+
+// Create a new logger sending messages of level o and higher to the slog.Handler h
+func NewSlogLogger(h slog.Handler, o Level) *SlogLogger {
+	return &SlogLogger{
+		handler:  h,
+		outLevel: int64(o),
+	}
+}
+
+// Send msg to the handler. Fields become attributes and the prefix and the name of the logger
+// that created msg, if any, are added as "prefix" and "logger" attributes.
+func (l *SlogLogger) Output(msg *Message) {
+	ctx := context.Background()
+	lvl := toSlogLevel(levelsOr(l.levels), msg.level)
+	if !l.handler.Enabled(ctx, lvl) {
+		return
+	}
+	var pc uintptr
+	if msg.caller != nil {
+		pc = msg.caller.PC
+	}
+	r := slog.NewRecord(msg.time, lvl, msg.m, pc)
+	if l.redactor != nil {
+		r.Message = l.redactor.Redact(msg.m)
+	}
+	if l.prefix != "" {
+		r.AddAttrs(slog.String("prefix", l.prefix))
+	}
+	if msg.name != "" {
+		r.AddAttrs(slog.String("logger", msg.name))
+	}
+	for _, fld := range msg.fields {
+		v := fld.Value
+		if l.redactor != nil {
+			v = l.redactor.redactField(fld.Key, v)
+		}
+		r.AddAttrs(slog.Any(fld.Key, v))
+	}
+	if l.wantStack(msg.level) && msg.stack != "" {
+		r.AddAttrs(slog.String("stack", msg.stack))
+	}
+	l.handler.Handle(ctx, r)
+}
+
+// Log a message at the standard level lvl, mapped to the logger's levels
+func (l *SlogLogger) log(lvl Level, format string, v ...interface{}) {
+	lvl = levelsOr(l.levels).Std(lvl)
+	if !enabled(lvl, l.GetLevel()) || l.closed {
+		return
+	}
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
+}
+
+// Sets the output level for this logger
+func (l *SlogLogger) Level(o Level) {
+	if levelsOr(l.levels).Valid(o) {
+		atomic.StoreInt64(&l.outLevel, int64(o))
+	}
+}
+
+// Sets the levels for this logger, instead of the registered ones
+func (l *SlogLogger) Levels(s *LevelSet) {
+	l.levels = s
+}
+
+// Sets the prefix for this logger
+func (l *SlogLogger) Prefix(p string) {
+	l.prefix = p
+}
+
+// Has no effect, the handler formats the time
+func (l *SlogLogger) TimeFormat(f string) {}
+
+// Has no effect, the handler decides the output format
+func (l *SlogLogger) Format(f int) {}
+
+// Has no effect, the handler decides how line breaks are written
+func (l *SlogLogger) Multiline(m int) {}
+
+// Has no effect, the handler is responsible for escaping its output
+func (l *SlogLogger) Sanitize(policy int) {}
+
+// Sets the redactor that masks secrets in messages before they are sent to the handler
+func (l *SlogLogger) Redact(r *Redactor) {
+	l.redactor = r
+}
+
+// Enables or disables passing the caller's program counter to the handler
+func (l *SlogLogger) ShowCaller(b bool) {
+	l.showCaller = b
+}
+
+// Sets the minimum level at which stack traces are added as a "stack" attribute. A negative
+// level disables stack traces.
+func (l *SlogLogger) StackTrace(lvl Level) {
+	l.stackTrace = lvl >= 0
+	l.stackLevel = lvl
+}
+
+// Stop sending messages. The handler is not closed.
+func (l *SlogLogger) Close() {
+	l.closed = true
+}
+
+// Logging functions
+func (l *SlogLogger) Fatal(format string, v ...interface{}) {
+	l.log(FATAL, format, v...)
+}
+
+func (l *SlogLogger) Error(format string, v ...interface{}) {
+	l.log(ERROR, format, v...)
+}
+
+func (l *SlogLogger) Warn(format string, v ...interface{}) {
+	l.log(WARN, format, v...)
+}
+
+func (l *SlogLogger) Info(format string, v ...interface{}) {
+	l.log(INFO, format, v...)
+}
+
+func (l *SlogLogger) Debug(format string, v ...interface{}) {
+	l.log(DEBUG, format, v...)
+}
+
+func (l *SlogLogger) Trace(format string, v ...interface{}) {
+	l.log(TRACE, format, v...)
+}
+
+func (l *SlogLogger) Print(lvl Level, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprint(v...)))
+}
+
+func (l *SlogLogger) Printf(lvl Level, format string, v ...interface{}) {
+	l.Output(l.newMessage(lvl, fmt.Sprintf(format, v...)))
+}
+
+func (l *SlogLogger) GetLevel() Level {
+	return Level(atomic.LoadInt64(&l.outLevel))
+}
+
+func (l *SlogLogger) IsFatal() bool {
+	return enabled(levelsOr(l.levels).Std(FATAL), l.GetLevel())
+}
+
+func (l *SlogLogger) IsError() bool {
+	return enabled(levelsOr(l.levels).Std(ERROR), l.GetLevel())
+}
+
+func (l *SlogLogger) IsWarn() bool {
+	return enabled(levelsOr(l.levels).Std(WARN), l.GetLevel())
+}
+
+func (l *SlogLogger) IsInfo() bool {
+	return enabled(levelsOr(l.levels).Std(INFO), l.GetLevel())
+}
+
+func (l *SlogLogger) IsDebug() bool {
+	return enabled(levelsOr(l.levels).Std(DEBUG), l.GetLevel())
+}
+
+func (l *SlogLogger) IsTrace() bool {
+	return enabled(levelsOr(l.levels).Std(TRACE), l.GetLevel())
+}
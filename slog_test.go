@@ -0,0 +1,92 @@
+//go:build go1.21
+
+package lumber
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestSlogHandler(t *testing.T) {
+	buf := &bufCloser{}
+	log := NewBasicLogger(buf, DEBUG)
+	log.TimeFormat("")
+	logger := slog.New(NewSlogHandler(log))
+
+	logger.Debug("debug")
+	logger.Log(context.Background(), slog.LevelDebug-4, "trace")
+	logger.With("svc", "api").WithGroup("req").Warn("hello", "id", 7, slog.Group("user", "name", "bob smith"))
+	logger.Error("failed", "password", "hunter2")
+
+	want := " DEBUG debug\n" +
+		" WARN  hello svc=api req.id=7 req.user.name=\"bob smith\"\n" +
+		" ERROR failed password=hunter2\n"
+	if out := buf.String(); out != want {
+		t.Errorf("Wrong output:\n%q\nexpected:\n%q", out, want)
+	}
+
+	buf.Reset()
+	log.Redact(NewRedactor())
+	logger.Error("failed", "password", "hunter2")
+	if out := buf.String(); out != " ERROR failed password=[REDACTED]\n" {
+		t.Errorf("Field not redacted: %q", out)
+	}
+}
+
+func TestSlogLogger(t *testing.T) {
+	buf := &bytes.Buffer{}
+	log := NewSlogLogger(slog.NewJSONHandler(buf, nil), TRACE)
+	log.Prefix("app")
+
+	log.Debug("below the handler level")
+	log.Warn("disk %d%% full", 91)
+
+	var rec map[string]interface{}
+	if err := json.Unmarshal(buf.Bytes(), &rec); err != nil {
+		t.Fatalf("Expected one JSON record, got %q: %s", buf.String(), err)
+	}
+	if rec["level"] != "WARN" || rec["msg"] != "disk 91% full" || rec["prefix"] != "app" {
+		t.Errorf("Wrong record %v", rec)
+	}
+}
+
+func TestSlogLevelSet(t *testing.T) {
+	set, err := NewLevelSet([]LevelDef{
+		{0, "DEBUG", "36", 7},
+		{10, "INFO", "32", 6},
+		{15, "NOTICE", "1", 5},
+		{20, "WARNING", "33", 4},
+		{30, "ERROR", "31", 3},
+		{40, "CRITICAL", "35", 2},
+	}, map[Level]Level{TRACE: 0, WARN: 20, FATAL: 40})
+	if err != nil {
+		t.Fatal(err)
+	}
+	RegisterLevels(set)
+	defer RegisterLevels(DefaultLevels())
+
+	if FromSlogLevel(slog.LevelError) != 30 || FromSlogLevel(slog.LevelDebug) != 0 || ToSlogLevel(15) != slog.LevelInfo || ToSlogLevel(40) != slog.LevelError+4 {
+		t.Error("Wrong slog level mapping")
+	}
+
+	buf := &bufCloser{}
+	log := NewBasicLogger(buf, 10)
+	log.TimeFormat("")
+	logger := slog.New(NewSlogHandler(log))
+	logger.Debug("not logged")
+	logger.Error("failed")
+	if out := buf.String(); out != " ERROR    failed\n" {
+		t.Errorf("Wrong output %q", out)
+	}
+
+	jsonBuf := &bytes.Buffer{}
+	sl := NewSlogLogger(slog.NewJSONHandler(jsonBuf, nil), 10)
+	sl.Printf(20, "warning")
+	var rec map[string]interface{}
+	if err := json.Unmarshal(jsonBuf.Bytes(), &rec); err != nil || rec["level"] != "WARN" {
+		t.Errorf("Wrong record %q", jsonBuf.String())
+	}
+}
@@ -0,0 +1,6 @@
+package lumber
+
+// An output that takes the syslog severity of each message, see ConsoleLogger.Output
+type syslogWriter interface {
+	writeSyslog(severity int, p []byte) error
+}
@@ -0,0 +1,13 @@
+//go:build windows || plan9
+
+package lumber
+
+import (
+	"fmt"
+	"io"
+)
+
+// The log/syslog package isn't available on this platform
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	return nil, fmt.Errorf("Syslog is not supported on this platform")
+}
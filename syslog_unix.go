@@ -0,0 +1,53 @@
+//go:build !windows && !plan9
+
+package lumber
+
+import (
+	"bytes"
+	"io"
+	"log/syslog"
+)
+
+// Writes messages to syslog with the severity of their level
+type syslogOut struct {
+	w *syslog.Writer
+}
+
+// Connect to syslog, see syslog.Dial
+func dialSyslog(network, addr, tag string) (io.WriteCloser, error) {
+	w, err := syslog.Dial(network, addr, syslog.LOG_INFO|syslog.LOG_USER, tag)
+	if err != nil {
+		return nil, err
+	}
+	return &syslogOut{w}, nil
+}
+
+func (s *syslogOut) Write(p []byte) (int, error) {
+	return s.w.Write(p)
+}
+
+func (s *syslogOut) Close() error {
+	return s.w.Close()
+}
+
+func (s *syslogOut) writeSyslog(severity int, p []byte) error {
+	// syslog adds the time and ends the line itself
+	m := string(bytes.TrimSpace(p))
+	switch severity {
+	case 0:
+		return s.w.Emerg(m)
+	case 1:
+		return s.w.Alert(m)
+	case 2:
+		return s.w.Crit(m)
+	case 3:
+		return s.w.Err(m)
+	case 4:
+		return s.w.Warning(m)
+	case 5:
+		return s.w.Notice(m)
+	case 6:
+		return s.w.Info(m)
+	}
+	return s.w.Debug(m)
+}
@@ -0,0 +1,76 @@
+package lumber
+
+import (
+	"fmt"
+	"sync/atomic"
+)
+
+// current verbosity for V
+var verbosity int32
+
+// Verbose is returned by V. It logs only if the verbosity level it was created with is enabled.
+type Verbose struct {
+	logger Logger
+}
+
+// Sets the verbosity, V(n) is enabled for every n up to and including it. The verbosity is
+// independent of the level of any logger.
+func SetVerbosity(n int) {
+	atomic.StoreInt32(&verbosity, int32(n))
+}
+
+// Returns the verbosity set by SetVerbosity
+func GetVerbosity() int {
+	return int(atomic.LoadInt32(&verbosity))
+}
+
+// Returns a Verbose that writes TRACE messages to the default logger if the verbosity is at least
+// n, and does nothing otherwise. V(0) is enabled unless the verbosity is negative. Messages are
+// written whatever the level of the logger is. When V(n) is disabled, the call only costs an
+// atomic load, but the arguments are still evaluated; use Enabled to guard expensive ones:
+//
+//	if v := lumber.V(2); v.Enabled() {
+//		v.Printf("state: %s", dump())
+//	}
+func V(n int) Verbose {
+	if int32(n) > atomic.LoadInt32(&verbosity) {
+		return Verbose{}
+	}
+	return Verbose{stdLog}
+}
+
+// Returns a Verbose writing to l instead, if v is enabled
+func (v Verbose) To(l Logger) Verbose {
+	if v.logger == nil {
+		return v
+	}
+	return Verbose{l}
+}
+
+// Reports whether v writes messages
+func (v Verbose) Enabled() bool {
+	return v.logger != nil
+}
+
+func (v Verbose) Print(args ...interface{}) {
+	if v.logger != nil {
+		v.output(fmt.Sprint(args...))
+	}
+}
+
+func (v Verbose) Printf(format string, args ...interface{}) {
+	if v.logger != nil {
+		v.output(fmt.Sprintf(format, args...))
+	}
+}
+
+// Write s whatever the level of the logger is, including the levels of the members of a
+// MultiLogger default logger
+func (v Verbose) output(s string) {
+	lvl := RegisteredLevels().Std(TRACE)
+	if l, ok := v.logger.(interface{ printFiltered(Level, string) }); ok {
+		l.printFiltered(lvl, s)
+		return
+	}
+	v.logger.Print(lvl, s)
+}
@@ -0,0 +1,156 @@
+package lumber
+
+import (
+	"flag"
+	"fmt"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+)
+
+// holds the current *vmodule, nil when no patterns are set
+var vmod atomic.Value
+
+// Per-file level overrides, glog style
+type vmodule struct {
+	spec     string
+	patterns []vmodulePattern
+	// call site pc -> *vmoduleSite
+	sites sync.Map
+}
+
+type vmodulePattern struct {
+	pattern string
+	level   Level
+}
+
+// What we know about the code at a pc: whether it belongs to this package, and otherwise
+// which level applies to it
+type vmoduleSite struct {
+	lumber  bool
+	matched bool
+	level   Level
+}
+
+// Set per-file levels from a comma-separated list of pattern=level pairs, e.g.
+// "pool=DEBUG,http/*=0". Messages logged from a source file matching a pattern are written if
+// they are at or above the pattern's level, instead of the logger's level. A pattern without a
+// slash is matched against the file name without ".go", a pattern with slashes against the end
+// of the file's path (without ".go"); patterns can use the wildcards of filepath.Match. An
+// empty spec removes all patterns.
+func SetVModule(spec string) error {
+	v := &vmodule{spec: spec}
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		eq := strings.LastIndex(part, "=")
+		if eq <= 0 {
+			return fmt.Errorf("Invalid vmodule entry %q: expected pattern=level", part)
+		}
+		pattern, lvlStr := part[:eq], part[eq+1:]
+		if _, err := filepath.Match(pattern, ""); err != nil {
+			return fmt.Errorf("Invalid vmodule pattern %q: %s", pattern, err)
+		}
+		lvl, err := ParseLevel(lvlStr)
+		if err != nil {
+			return fmt.Errorf("Invalid vmodule entry %q: %s", part, err)
+		}
+		v.patterns = append(v.patterns, vmodulePattern{pattern, lvl})
+	}
+	if len(v.patterns) == 0 {
+		vmod.Store((*vmodule)(nil))
+	} else {
+		vmod.Store(v)
+	}
+	return nil
+}
+
+// Returns the spec set by SetVModule
+func GetVModule() string {
+	if v, _ := vmod.Load().(*vmodule); v != nil {
+		return v.spec
+	}
+	return ""
+}
+
+// Returns a flag.Value for SetVModule, for use with flag.Var
+func VModuleFlag() flag.Value {
+	return vmoduleFlag{}
+}
+
+type vmoduleFlag struct{}
+
+func (vmoduleFlag) String() string     { return GetVModule() }
+func (vmoduleFlag) Set(s string) error { return SetVModule(s) }
+
+// Reports whether a message of level lvl should be written by a logger whose level is outLevel,
+// taking the vmodule patterns for the calling code into account
+func enabled(lvl, outLevel Level) bool {
+	v, _ := vmod.Load().(*vmodule)
+	if v == nil {
+		return lvl >= outLevel
+	}
+	if siteLevel, ok := v.callerLevel(); ok {
+		return lvl >= siteLevel
+	}
+	return lvl >= outLevel
+}
+
+// Returns the level set for the code that called into the logger, if a pattern matches it
+func (v *vmodule) callerLevel() (Level, bool) {
+	pcs := make([]uintptr, 32)
+	n := runtime.Callers(3, pcs)
+	for _, pc := range pcs[:n] {
+		site := v.site(pc)
+		if !site.lumber {
+			return site.level, site.matched
+		}
+	}
+	return 0, false
+}
+
+// Returns what we know about pc, resolving it the first time
+func (v *vmodule) site(pc uintptr) *vmoduleSite {
+	if site, ok := v.sites.Load(pc); ok {
+		return site.(*vmoduleSite)
+	}
+	site := &vmoduleSite{lumber: true}
+	frames := runtime.CallersFrames([]uintptr{pc})
+	for {
+		frame, more := frames.Next()
+		if !isLumberFrame(frame) {
+			site.lumber = false
+			site.level, site.matched = v.match(frame.File)
+			break
+		}
+		if !more {
+			break
+		}
+	}
+	v.sites.Store(pc, site)
+	return site
+}
+
+// Returns the level of the first pattern matching file
+func (v *vmodule) match(file string) (Level, bool) {
+	file = strings.TrimSuffix(filepath.ToSlash(file), ".go")
+	base := file[strings.LastIndex(file, "/")+1:]
+	for _, p := range v.patterns {
+		name := base
+		if strings.Contains(p.pattern, "/") {
+			// compare with as many trailing path elements as the pattern has
+			parts := strings.Split(file, "/")
+			if k := strings.Count(p.pattern, "/") + 1; k <= len(parts) {
+				name = strings.Join(parts[len(parts)-k:], "/")
+			}
+		}
+		if ok, _ := filepath.Match(p.pattern, name); ok {
+			return p.level, true
+		}
+	}
+	return 0, false
+}
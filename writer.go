@@ -0,0 +1,102 @@
+package lumber
+
+import (
+	"bytes"
+	"log"
+	"regexp"
+	"sync"
+	"time"
+	"unicode/utf8"
+)
+
+// matches the date and time the standard library's log package puts in front of messages
+// (Ldate, Ltime and Lmicroseconds flags)
+var stdTimestamp = regexp.MustCompile(`^(\d{4}/\d{2}/\d{2} )?(\d{2}:\d{2}:\d{2}(\.\d{6})? )?`)
+
+// Writer is an io.Writer that sends every line written to it to a Logger at a fixed level.
+// It can be used as the output of the standard library's log package, or anything else that
+// writes lines of text.
+type Writer struct {
+	mu             *sync.Mutex
+	logger         Logger
+	level          Level
+	buf            []byte
+	stripTimestamp bool
+	// lines longer than this are split, 0 means no limit
+	maxLength int
+	// fields added to every message, if not nil
+	fields func() []Field
+}
+
+// Create a new Writer that logs each line to l at level lvl. A standard level (TRACE..FATAL) is
+// mapped to the registered levels, like the level of the Info..Fatal methods. Timestamps added by
+// the standard library's log package are removed, since the logger adds its own.
+func NewWriter(l Logger, lvl Level) *Writer {
+	return &Writer{
+		mu:             &sync.Mutex{},
+		logger:         l,
+		level:          lvl,
+		stripTimestamp: true,
+	}
+}
+
+// Create a standard library *log.Logger that writes to l at level lvl. This is useful for
+// packages that only accept a *log.Logger, such as http.Server's ErrorLog.
+func NewStdLogger(l Logger, lvl Level) *log.Logger {
+	return log.New(NewWriter(l, lvl), "", 0)
+}
+
+// Log every complete line in p. An incomplete last line is kept until the rest of it is written
+// or Flush is called.
+func (w *Writer) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	n := len(p)
+	if w.stripTimestamp && len(w.buf) == 0 {
+		// the log package writes one message per call, so only the start can be a timestamp
+		p = stdTimestamp.ReplaceAll(p, nil)
+	}
+	w.buf = append(w.buf, p...)
+	for {
+		i, skip := bytes.IndexByte(w.buf, '\n'), 1
+		if w.maxLength > 0 && (i > w.maxLength || i < 0 && len(w.buf) > w.maxLength) {
+			// don't buffer endlessly, split long lines without breaking up a UTF-8 character
+			i, skip = w.maxLength, 0
+			for i > 0 && !utf8.RuneStart(w.buf[i]) {
+				i--
+			}
+			if i == 0 {
+				i = w.maxLength
+			}
+		}
+		if i < 0 {
+			break
+		}
+		w.logLine(w.buf[:i])
+		w.buf = w.buf[i+skip:]
+	}
+	return n, nil
+}
+
+// Log any incomplete line that has been written
+func (w *Writer) Flush() {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if len(w.buf) > 0 {
+		w.logLine(w.buf)
+		w.buf = nil
+	}
+}
+
+func (w *Writer) logLine(line []byte) {
+	lvl := RegisteredLevels().Std(w.level)
+	if lvl < w.logger.GetLevel() {
+		return
+	}
+	line = bytes.TrimSuffix(line, []byte{'\r'})
+	if w.fields == nil {
+		w.logger.Print(lvl, string(line))
+		return
+	}
+	w.logger.Output(&Message{level: lvl, m: string(line), time: time.Now(), fields: w.fields()})
+}